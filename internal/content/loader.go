@@ -0,0 +1,40 @@
+package content
+
+import (
+	"context"
+)
+
+// DefaultWorkers is how many files a Loader reads at once unless told otherwise.
+const DefaultWorkers = 4
+
+// Loader reads file contents on behalf of the UI with a bounded number of
+// concurrent reads. Callers may start as many loads as they like (each one
+// typically in its own tea.Cmd goroutine); the excess simply waits for a free
+// worker, so tagging hundreds of files doesn't open hundreds of files at once.
+// A Loader is safe for concurrent use.
+type Loader struct {
+	slots   chan struct{} // One token per worker; a read holds a token while it runs
+	maxSize int64         // Files bigger than this are not read; 0 means no limit
+}
+
+// NewLoader creates a Loader that reads at most workers files at a time and
+// skips files bigger than maxSize bytes (0 for no limit).
+func NewLoader(workers int, maxSize int64) *Loader {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Loader{slots: make(chan struct{}, workers), maxSize: maxSize}
+}
+
+// Load waits for a free worker and reads the file at path with Read. It gives
+// up and returns ctx.Err() if ctx is cancelled while waiting.
+func (l *Loader) Load(ctx context.Context, path string) (File, error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return File{}, ctx.Err()
+	}
+	defer func() { <-l.slots }()
+
+	return Read(path, l.maxSize)
+}
@@ -0,0 +1,133 @@
+package content
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive span of 1-based line numbers within a file.
+type LineRange struct {
+	Start int // First line of the range
+	End   int // Last line of the range, >= Start
+}
+
+// String formats the range as "12" or "12–40".
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d–%d", r.Start, r.End)
+}
+
+// Len returns the number of lines in the range.
+func (r LineRange) Len() int {
+	return r.End - r.Start + 1
+}
+
+// Contains reports whether other lies entirely inside r.
+func (r LineRange) Contains(other LineRange) bool {
+	return r.Start <= other.Start && other.End <= r.End
+}
+
+// FormatRanges formats ranges as "lines 12–40, 55–60" (or "line 12").
+func FormatRanges(ranges []LineRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	if len(ranges) == 1 && ranges[0].Len() == 1 {
+		return "line " + parts[0]
+	}
+	return "lines " + strings.Join(parts, ", ")
+}
+
+// MergeRanges returns the ranges sorted by start, with overlapping and
+// adjacent ranges joined into one.
+func MergeRanges(ranges []LineRange) []LineRange {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(a, b LineRange) int { return a.Start - b.Start })
+	var merged []LineRange
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End+1 {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Covers reports whether every line of r is in one of the merged ranges.
+func Covers(ranges []LineRange, r LineRange) bool {
+	for _, existing := range ranges {
+		if existing.Contains(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveRange returns merged ranges with every line of r taken out of them,
+// splitting a range in two when r falls in its middle.
+func RemoveRange(ranges []LineRange, r LineRange) []LineRange {
+	var out []LineRange
+	for _, existing := range ranges {
+		if existing.End < r.Start || existing.Start > r.End {
+			out = append(out, existing)
+			continue
+		}
+		if existing.Start < r.Start {
+			out = append(out, LineRange{existing.Start, r.Start - 1})
+		}
+		if existing.End > r.End {
+			out = append(out, LineRange{r.End + 1, existing.End})
+		}
+	}
+	return out
+}
+
+// Excerpt renders only the given lines of text, each prefixed with its line
+// number, with a "… lines X–Y omitted …" marker wherever lines were skipped.
+// ranges must be merged (see MergeRanges); parts past the end of text are dropped.
+func Excerpt(text string, ranges []LineRange) string {
+	lines := splitLines(text)
+	width := len(strconv.Itoa(len(lines)))
+
+	var b strings.Builder
+	next := 1 // First line not yet written or skipped
+	for _, r := range ranges {
+		start, end := max(r.Start, next), min(r.End, len(lines))
+		if start > end {
+			continue
+		}
+		if start > next {
+			fmt.Fprintf(&b, "… %s omitted …\n", FormatRanges([]LineRange{{next, start - 1}}))
+		}
+		for n := start; n <= end; n++ {
+			fmt.Fprintf(&b, "%*d  %s", width, n, strings.TrimSuffix(lines[n-1], "\n"))
+			b.WriteString("\n")
+		}
+		next = end + 1
+	}
+	if next <= len(lines) {
+		fmt.Fprintf(&b, "… %s omitted …\n", FormatRanges([]LineRange{{next, len(lines)}}))
+	}
+	return b.String()
+}
+
+// LineCount returns the number of lines in text, the last one counted even
+// without a final newline, as Excerpt numbers them.
+func LineCount(text string) int {
+	return len(splitLines(text))
+}
+
+// splitLines splits text into lines, each keeping its newline.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1] // SplitAfter leaves an empty string after a final newline
+	}
+	return lines
+}
@@ -0,0 +1,148 @@
+package content
+
+import (
+	"slices"
+	"testing"
+)
+
+// lines is shorthand for a list of ranges given as start, end pairs.
+func lines(bounds ...int) []LineRange {
+	var ranges []LineRange
+	for i := 0; i+1 < len(bounds); i += 2 {
+		ranges = append(ranges, LineRange{Start: bounds[i], End: bounds[i+1]})
+	}
+	return ranges
+}
+
+func TestFormatRanges(t *testing.T) {
+	tests := []struct {
+		ranges []LineRange
+		want   string
+	}{
+		{lines(12, 12), "line 12"},
+		{lines(12, 40), "lines 12–40"},
+		{lines(1, 3, 7, 7, 10, 12), "lines 1–3, 7, 10–12"},
+	}
+	for _, tt := range tests {
+		if got := FormatRanges(tt.ranges); got != tt.want {
+			t.Errorf("FormatRanges(%v) = %q, want %q", tt.ranges, got, tt.want)
+		}
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	tests := []struct {
+		in, want []LineRange
+	}{
+		{nil, nil},
+		{lines(10, 20, 1, 5), lines(1, 5, 10, 20)}, // Sorted
+		{lines(1, 5, 3, 8), lines(1, 8)},           // Overlapping
+		{lines(1, 5, 6, 8), lines(1, 8)},           // Adjacent
+		{lines(1, 10, 3, 4), lines(1, 10)},         // Nested
+		{lines(1, 5, 7, 8), lines(1, 5, 7, 8)},     // A gap stays
+		{lines(7, 9, 1, 2, 3, 6), lines(1, 9)},     // Chained
+		{lines(4, 4, 4, 4, 5, 5), lines(4, 5)},     // Duplicates
+	}
+	for _, tt := range tests {
+		in := slices.Clone(tt.in)
+		if got := MergeRanges(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("MergeRanges(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+		if !slices.Equal(tt.in, in) {
+			t.Errorf("MergeRanges(%v) modified its argument", in)
+		}
+	}
+}
+
+func TestCovers(t *testing.T) {
+	ranges := lines(1, 5, 10, 20)
+	tests := []struct {
+		r    LineRange
+		want bool
+	}{
+		{LineRange{1, 5}, true},
+		{LineRange{12, 15}, true},
+		{LineRange{5, 10}, false}, // Spans the gap
+		{LineRange{21, 21}, false},
+	}
+	for _, tt := range tests {
+		if got := Covers(ranges, tt.r); got != tt.want {
+			t.Errorf("Covers(%v, %v) = %v, want %v", ranges, tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveRange(t *testing.T) {
+	tests := []struct {
+		ranges []LineRange
+		r      LineRange
+		want   []LineRange
+	}{
+		{lines(1, 10), LineRange{4, 6}, lines(1, 3, 7, 10)}, // Split in two
+		{lines(1, 10), LineRange{1, 3}, lines(4, 10)},
+		{lines(1, 10), LineRange{8, 12}, lines(1, 7)},
+		{lines(1, 10), LineRange{1, 10}, nil},
+		{lines(1, 3, 5, 8, 10, 12), LineRange{2, 10}, lines(1, 1, 11, 12)}, // Across several
+		{lines(1, 3), LineRange{5, 6}, lines(1, 3)},                        // Nothing to remove
+	}
+	for _, tt := range tests {
+		if got := RemoveRange(tt.ranges, tt.r); !slices.Equal(got, tt.want) {
+			t.Errorf("RemoveRange(%v, %v) = %v, want %v", tt.ranges, tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	text := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	tests := []struct {
+		name   string
+		ranges []LineRange
+		want   string
+	}{
+		{
+			name:   "middle",
+			ranges: lines(3, 4),
+			want:   "… lines 1–2 omitted …\n 3  three\n 4  four\n… lines 5–10 omitted …\n",
+		},
+		{
+			name:   "two ranges",
+			ranges: lines(1, 1, 9, 10),
+			want:   " 1  one\n… lines 2–8 omitted …\n 9  nine\n10  ten\n",
+		},
+		{
+			name:   "a single skipped line",
+			ranges: lines(1, 4, 6, 10),
+			want:   " 1  one\n 2  two\n 3  three\n 4  four\n… line 5 omitted …\n 6  six\n 7  seven\n 8  eight\n 9  nine\n10  ten\n",
+		},
+		{
+			name:   "past the end is dropped",
+			ranges: lines(10, 15, 20, 30),
+			want:   "… lines 1–9 omitted …\n10  ten\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Excerpt(text, tt.ranges); got != tt.want {
+				t.Errorf("Excerpt(%v) =\n%s\nwant\n%s", tt.ranges, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"one", 1},
+		{"one\n", 1},
+		{"one\ntwo", 2},
+		{"one\n\n", 2},
+	}
+	for _, tt := range tests {
+		if got := LineCount(tt.text); got != tt.want {
+			t.Errorf("LineCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
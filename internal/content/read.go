@@ -0,0 +1,122 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxSize is the largest file whose content is loaded unless configured otherwise.
+const DefaultMaxSize = 1 << 20 // 1 MiB
+
+// sniffLen is how much of a file is searched for NUL bytes, like git and grep do.
+const sniffLen = 8000
+
+// Kind tells whether a file's content could be used as text.
+type Kind int
+
+const (
+	Text     Kind = iota // 0: Readable text; File.Text holds the content
+	Binary               // 1: Contains NUL bytes or invalid UTF-8; content dropped
+	TooLarge             // 2: Bigger than the size cap; content not read
+)
+
+// File is the result of reading a file for inclusion in a prompt.
+type File struct {
+	Text string // The content, only set for Kind == Text
+	Kind Kind   // Whether the content was usable
+	Size int64  // Size of the file in bytes
+}
+
+// Placeholder describes why the content of a non-text file was left out,
+// e.g. "binary file, 12.3 KB". It returns "" for text files.
+func (f File) Placeholder() string {
+	switch f.Kind {
+	case Binary:
+		return "binary file, " + FormatSize(f.Size)
+	case TooLarge:
+		return "file too large, " + FormatSize(f.Size)
+	}
+	return ""
+}
+
+// Read loads the file at path for use as prompt text. Files bigger than
+// maxSize bytes (when maxSize > 0) are not read at all, and binary files are
+// detected and dropped; both are reported through File.Kind rather than as errors.
+func Read(path string, maxSize int64) (File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return File{}, err
+	}
+	if info.IsDir() {
+		return File{}, fmt.Errorf("%s is a directory", path)
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return File{Kind: TooLarge, Size: info.Size()}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		// The file grew between the stat and the read.
+		return File{Kind: TooLarge, Size: int64(len(data))}, nil
+	}
+	if IsBinary(data) {
+		return File{Kind: Binary, Size: int64(len(data))}, nil
+	}
+	return File{Text: string(data), Kind: Text, Size: int64(len(data))}, nil
+}
+
+// IsBinary reports whether data looks like a binary file: it has a NUL byte
+// near the start, or isn't valid UTF-8.
+func IsBinary(data []byte) bool {
+	for _, b := range data[:min(len(data), sniffLen)] {
+		if b == 0 {
+			return true
+		}
+	}
+	return !utf8.Valid(data)
+}
+
+// FormatSize formats a byte count for humans, e.g. "512 B", "12.3 KB" or "4.0 MB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// ParseSize parses a byte count with an optional b, k, m or g suffix (powers
+// of 1024), such as "512", "20k", "1.5m" or "2GB".
+func ParseSize(s string) (int64, error) {
+	num := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "b")
+	multiplier := int64(1)
+	if num != "" {
+		switch num[len(num)-1] {
+		case 'k':
+			multiplier = 1 << 10
+		case 'm':
+			multiplier = 1 << 20
+		case 'g':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			num = num[:len(num)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
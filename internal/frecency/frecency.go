@@ -0,0 +1,158 @@
+package frecency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"prompty/internal/xdg"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRecordsPerProject caps how many files are remembered per project; the
+// lowest scoring ones are forgotten first.
+const maxRecordsPerProject = 500
+
+// Record is the usage history of a single file within a project.
+type Record struct {
+	Count    int       `json:"count"`     // How many times the file was tagged
+	LastUsed time.Time `json:"last_used"` // When it was last tagged
+}
+
+// Store remembers which files each project tags and how recently, so that
+// search can rank them higher. Projects are keyed by their root directory.
+// All methods are safe for concurrent use.
+type Store struct {
+	path string // JSON file backing the store; empty for an in-memory store
+
+	mu       sync.Mutex
+	projects map[string]map[string]Record // Project root -> relative path -> record
+}
+
+// DefaultPath returns the standard location of the frecency file under the XDG data dir.
+func DefaultPath() string {
+	return filepath.Join(xdg.DataDir(), "frecency.json")
+}
+
+// Open loads the store from path. A missing file is not an error; the store
+// simply starts empty and the file is created on the first Save.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, projects: make(map[string]map[string]Record)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read frecency data: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.projects); err != nil {
+		return s, fmt.Errorf("failed to parse frecency data %s: %w", path, err)
+	}
+	if s.projects == nil {
+		s.projects = make(map[string]map[string]Record)
+	}
+	return s, nil
+}
+
+// Touch records that a file in project was used (tagged) at now.
+func (s *Store) Touch(project, path string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.projects[project]
+	if records == nil {
+		records = make(map[string]Record)
+		s.projects[project] = records
+	}
+	record := records[path]
+	record.Count++
+	record.LastUsed = now
+	records[path] = record
+
+	if len(records) > maxRecordsPerProject {
+		s.prune(records, now)
+	}
+}
+
+// Scores returns the frecency score of every remembered file in project.
+// Files that were never used are absent (score 0).
+func (s *Store) Scores(project string, now time.Time) map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.projects[project]
+	scores := make(map[string]float64, len(records))
+	for path, record := range records {
+		scores[path] = score(record, now)
+	}
+	return scores
+}
+
+// Boost converts a frecency score into a bonus on the fuzzy matcher's scale.
+// It grows logarithmically, so a favourite file rises noticeably but can't
+// outrank a much better textual match.
+func Boost(frecency float64) int {
+	if frecency <= 0 {
+		return 0
+	}
+	return int(10 * math.Log2(1+frecency/10))
+}
+
+// Save writes the store to its file, replacing it atomically.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.projects, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode frecency data: %w", err)
+	}
+
+	if err := xdg.WriteFile(s.path, ".frecency-*.json", data); err != nil {
+		return fmt.Errorf("failed to save frecency data: %w", err)
+	}
+	return nil
+}
+
+// score weights how often a file was used by how recently, in buckets similar
+// to the ones browsers use for address bar suggestions.
+func score(record Record, now time.Time) float64 {
+	age := now.Sub(record.LastUsed)
+	var weight float64
+	switch {
+	case age < 4*24*time.Hour:
+		weight = 100
+	case age < 14*24*time.Hour:
+		weight = 70
+	case age < 31*24*time.Hour:
+		weight = 50
+	case age < 90*24*time.Hour:
+		weight = 30
+	default:
+		weight = 10
+	}
+	return float64(record.Count) * weight
+}
+
+// prune drops the lowest scoring records until the project is back under its cap.
+// The caller must hold s.mu.
+func (s *Store) prune(records map[string]Record, now time.Time) {
+	paths := make([]string, 0, len(records))
+	for path := range records {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return score(records[paths[i]], now) < score(records[paths[j]], now)
+	})
+	for _, path := range paths[:len(paths)-maxRecordsPerProject] {
+		delete(records, path)
+	}
+}
@@ -0,0 +1,86 @@
+package frecency
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompty", "frecency.json")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of a missing file failed: %v", err)
+	}
+	s.Touch("/src/app", "main.go", now)
+	s.Touch("/src/app", "main.go", now)
+	s.Touch("/src/app", "old.go", now.Add(-60*24*time.Hour))
+	s.Touch("/src/lib", "lib.go", now)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of a saved file failed: %v", err)
+	}
+	scores := reopened.Scores("/src/app", now)
+	if len(scores) != 2 {
+		t.Fatalf("Scores() = %v, want main.go and old.go only", scores)
+	}
+	// Two recent uses outweigh a single old one.
+	if scores["main.go"] != 200 || scores["old.go"] != 30 {
+		t.Errorf("Scores() = %v, want main.go 200 and old.go 30", scores)
+	}
+	if _, ok := reopened.Scores("/src/lib", now)["lib.go"]; !ok {
+		t.Error("Scores() lost the records of a second project")
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frecency.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(path)
+	if err == nil {
+		t.Error("Open() of a corrupt file succeeded, want an error")
+	}
+	// The store is still usable, it just starts empty.
+	s.Touch("/p", "a.go", time.Now())
+	if len(s.Scores("/p", time.Now())) != 1 {
+		t.Error("a store opened from a corrupt file doesn't record uses")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "frecency.json"))
+	now := time.Now()
+	s.Touch("/p", "favourite.go", now)
+	s.Touch("/p", "favourite.go", now)
+	for i := range maxRecordsPerProject {
+		s.Touch("/p", fmt.Sprintf("gen/%d.go", i), now)
+	}
+	scores := s.Scores("/p", now)
+	if len(scores) != maxRecordsPerProject {
+		t.Errorf("got %d records, want the cap of %d", len(scores), maxRecordsPerProject)
+	}
+	if _, ok := scores["favourite.go"]; !ok {
+		t.Error("pruning dropped the highest scoring file")
+	}
+}
+
+func TestBoost(t *testing.T) {
+	if got := Boost(0); got != 0 {
+		t.Errorf("Boost(0) = %d, want 0", got)
+	}
+	// The bonus grows with the score, but ever more slowly.
+	low, mid, high := Boost(100), Boost(1000), Boost(10000)
+	if !(low < mid && mid < high) || high-mid > mid {
+		t.Errorf("Boost(100, 1000, 10000) = %d, %d, %d; want slow growth", low, mid, high)
+	}
+}
@@ -0,0 +1,130 @@
+package glob
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Compile translates a shell-style glob into a regular expression that matches
+// whole slash-separated paths. The syntax is the one used by .gitignore files:
+//
+//	*.go      '*' matches any run of characters except '/'
+//	file?.txt '?' matches any single character except '/'
+//	[a-z].md  a character class; [!a-z] or [^a-z] negates it
+//	docs/**   '**' in a segment of its own matches any number of directories, including none
+//	\*.txt    a backslash makes the next character literal
+func Compile(pattern string) (*regexp.Regexp, error) {
+	expr, err := translate(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+// Match reports whether the slash-separated path matches the glob pattern.
+func Match(pattern, path string) (bool, error) {
+	re, err := Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(path), nil
+}
+
+// HasMeta reports whether pattern contains any glob syntax, i.e. whether it
+// matches anything other than itself.
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// classEscaper escapes the characters that are literal inside a glob character
+// class but special inside a regexp one.
+var classEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+
+// translate converts a glob to an (unanchored) regular expression.
+func translate(pattern string) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				// "**" only has its special meaning as a whole path segment.
+				atStart := i == 0 || pattern[i-1] == '/'
+				end := i + 2
+				atEnd := end == len(pattern) || pattern[end] == '/'
+				if atStart && atEnd {
+					switch {
+					case end == len(pattern):
+						out.WriteString(".*") // "foo/**": everything inside foo
+					default:
+						out.WriteString("(?:.*/)?") // "**/foo" or "a/**/b": zero or more directories
+						end++                       // The '/' is part of the group
+					}
+					i = end - 1
+					continue
+				}
+				// Otherwise "**" behaves like "*".
+				for i+1 < len(pattern) && pattern[i+1] == '*' {
+					i++
+				}
+			}
+			out.WriteString("[^/]*")
+		case '?':
+			out.WriteString("[^/]")
+		case '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				return "", fmt.Errorf("invalid glob %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : end]
+			out.WriteByte('[')
+			if strings.HasPrefix(class, "!") || strings.HasPrefix(class, "^") {
+				out.WriteByte('^')
+				class = class[1:]
+			}
+			out.WriteString(classEscaper.Replace(class))
+			out.WriteByte(']')
+			i = end
+		case '\\':
+			if i+1 < len(pattern) {
+				// The escaped character may be several bytes long, like any literal.
+				_, size := utf8.DecodeRuneInString(pattern[i+1:])
+				out.WriteString(regexp.QuoteMeta(pattern[i+1 : i+1+size]))
+				i += size
+			} else {
+				out.WriteString(`\\`)
+			}
+		default:
+			// Copy the run of literal text up to the next special character
+			// whole, so multi-byte UTF-8 characters stay intact.
+			end := i + 1
+			for end < len(pattern) && !strings.ContainsRune(`*?[\`, rune(pattern[end])) {
+				end++
+			}
+			out.WriteString(regexp.QuoteMeta(pattern[i:end]))
+			i = end - 1
+		}
+	}
+	return out.String(), nil
+}
+
+// classEnd returns the index of the ']' closing the character class that
+// starts at pattern[start], or -1. A ']' right after the opening bracket (or
+// its negation) is taken literally, as in shells.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,59 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"*.go", []string{"main.go", ".go"}, []string{"cmd/main.go", "main.gox"}},
+		{"file?.txt", []string{"file1.txt"}, []string{"file.txt", "file12.txt", "file/.txt"}},
+		{"[a-c].md", []string{"a.md", "c.md"}, []string{"d.md", "ab.md"}},
+		{"[!a-c].md", []string{"d.md"}, []string{"a.md"}},
+		{"[]x].md", []string{"].md", "x.md"}, []string{"y.md"}}, // A leading ']' is literal
+		{"docs/**", []string{"docs/a", "docs/a/b/c.md"}, []string{"docs", "src/docs/a"}},
+		{"**/test", []string{"test", "a/test", "a/b/test"}, []string{"atest", "test/a"}},
+		{"a/**/b", []string{"a/b", "a/x/b", "a/x/y/b"}, []string{"ab", "a/xb"}},
+		{"a**b", []string{"ab", "axxb"}, []string{"a/b"}}, // Not a whole segment: like '*'
+		{`\*.txt`, []string{"*.txt"}, []string{"a.txt"}},
+		{"a+b(1).go", []string{"a+b(1).go"}, []string{"aab1.go"}},   // Regexp syntax is literal
+		{"café/*.go", []string{"café/x.go"}, []string{"cafe/x.go"}}, // Multi-byte literals
+		{"?.md", []string{"é.md"}, []string{"ab.md"}},               // '?' is one character, not one byte
+		{`\é*`, []string{"é.txt"}, []string{"e.txt"}},
+		{"[éè].txt", []string{"è.txt"}, []string{"e.txt"}},
+	}
+	for _, tt := range tests {
+		for _, path := range tt.match {
+			if ok, err := Match(tt.pattern, path); err != nil || !ok {
+				t.Errorf("Match(%q, %q) = %v, %v; want a match", tt.pattern, path, ok, err)
+			}
+		}
+		for _, path := range tt.noMatch {
+			if ok, err := Match(tt.pattern, path); err != nil || ok {
+				t.Errorf("Match(%q, %q) = %v, %v; want no match", tt.pattern, path, ok, err)
+			}
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	if _, err := Compile("[a-"); err == nil {
+		t.Error(`Compile("[a-") succeeded, want an unterminated class error`)
+	}
+}
+
+func TestHasMeta(t *testing.T) {
+	for pattern, want := range map[string]bool{
+		"internal/search": false,
+		"*.go":            true,
+		"file?.txt":       true,
+		"[ab].md":         true,
+		`a\b`:             true,
+	} {
+		if got := HasMeta(pattern); got != want {
+			t.Errorf("HasMeta(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"prompty/internal/xdg"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRecentPerProject caps how many past queries are remembered per project;
+// the oldest ones are forgotten first.
+const maxRecentPerProject = 200
+
+// Entry is a query that was run, or saved under a name.
+type Entry struct {
+	Name  string    `json:"name,omitempty"`  // Name given when the query was saved; empty for history
+	Query string    `json:"query"`           // The search input, filters included
+	Mode  string    `json:"mode"`            // The search mode it was run in, e.g. "Files"
+	Match string    `json:"match,omitempty"` // How the text was matched, e.g. "regex"; empty for fuzzy
+	Used  time.Time `json:"used"`            // When it was last run (or saved)
+}
+
+// project is what the store keeps for one project.
+type project struct {
+	Recent []Entry `json:"recent,omitempty"` // Most recent first
+	Saved  []Entry `json:"saved,omitempty"`  // In the order they were saved
+}
+
+// Store remembers the queries each project ran, and the ones saved under a
+// name, so they can be recalled later. Projects are keyed by their root
+// directory. All methods are safe for concurrent use.
+type Store struct {
+	path string // JSON file backing the store; empty for an in-memory store
+
+	mu       sync.Mutex
+	projects map[string]*project
+}
+
+// DefaultPath returns the standard location of the history file under the XDG data dir.
+func DefaultPath() string {
+	return filepath.Join(xdg.DataDir(), "history.json")
+}
+
+// Open loads the store from path. A missing file is not an error; the store
+// simply starts empty and the file is created on the first Save.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, projects: make(map[string]*project)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read search history: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.projects); err != nil {
+		return s, fmt.Errorf("failed to parse search history %s: %w", path, err)
+	}
+	if s.projects == nil {
+		s.projects = make(map[string]*project)
+	}
+	return s, nil
+}
+
+// sameSearch reports whether a and b run the same search, whatever their names.
+func sameSearch(a, b Entry) bool {
+	return a.Query == b.Query && a.Mode == b.Mode && a.Match == b.Match
+}
+
+// Add records that entry's query was run at entry.Used; its name is ignored.
+// Running a query again moves it to the front instead of adding a duplicate,
+// and a query that extends the most recent one (typing on after a pause)
+// replaces it, so the history holds what was searched rather than every keystroke.
+func (s *Store) Add(projectRoot string, entry Entry) {
+	entry.Name = ""
+	entry.Query = strings.TrimSpace(entry.Query)
+	if entry.Query == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(projectRoot)
+	recent := make([]Entry, 0, len(p.Recent)+1)
+	recent = append(recent, entry)
+	for i, e := range p.Recent {
+		if sameSearch(e, entry) {
+			continue
+		}
+		if i == 0 && e.Mode == entry.Mode && e.Match == entry.Match && strings.HasPrefix(entry.Query, e.Query) {
+			continue // Superseded by the longer query
+		}
+		recent = append(recent, e)
+	}
+	p.Recent = recent[:min(len(recent), maxRecentPerProject)]
+}
+
+// Recent returns the queries run in a project, most recent first.
+func (s *Store) Recent(projectRoot string) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.projects[projectRoot]; p != nil {
+		return append([]Entry(nil), p.Recent...)
+	}
+	return nil
+}
+
+// SaveQuery saves entry under entry.Name, replacing any saved query with that name.
+func (s *Store) SaveQuery(projectRoot string, entry Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(projectRoot)
+	for i := range p.Saved {
+		if p.Saved[i].Name == entry.Name {
+			p.Saved[i] = entry
+			return
+		}
+	}
+	p.Saved = append(p.Saved, entry)
+}
+
+// Saved returns the named queries of a project, in the order they were saved.
+func (s *Store) Saved(projectRoot string) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.projects[projectRoot]; p != nil {
+		return append([]Entry(nil), p.Saved...)
+	}
+	return nil
+}
+
+// Delete forgets an entry: the saved query with its name, or, for an entry
+// without a name, that query in the history.
+func (s *Store) Delete(projectRoot string, entry Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectRoot]
+	if p == nil {
+		return
+	}
+	drop := func(entries []Entry, match func(Entry) bool) []Entry {
+		kept := entries[:0]
+		for _, e := range entries {
+			if !match(e) {
+				kept = append(kept, e)
+			}
+		}
+		return kept
+	}
+	if entry.Name != "" {
+		p.Saved = drop(p.Saved, func(e Entry) bool { return e.Name == entry.Name })
+	} else {
+		p.Recent = drop(p.Recent, func(e Entry) bool { return sameSearch(e, entry) })
+	}
+}
+
+// Save writes the store to its file, replacing it atomically.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.projects, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode search history: %w", err)
+	}
+
+	if err := xdg.WriteFile(s.path, ".history-*.json", data); err != nil {
+		return fmt.Errorf("failed to save search history: %w", err)
+	}
+	return nil
+}
+
+// project returns the entries of a project, creating them if needed.
+// The caller must hold s.mu.
+func (s *Store) project(projectRoot string) *project {
+	p := s.projects[projectRoot]
+	if p == nil {
+		p = &project{}
+		s.projects[projectRoot] = p
+	}
+	return p
+}
@@ -0,0 +1,82 @@
+package history
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// queries returns the query texts of entries, in order.
+func queries(entries []Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Query)
+	}
+	return out
+}
+
+func TestAdd(t *testing.T) {
+	s, _ := Open("") // In memory
+	now := time.Now()
+	for _, q := range []string{"mod", "model", "  ", "view", "model", "ext:go"} {
+		s.Add("/p", Entry{Query: q, Mode: "Files", Used: now})
+	}
+	s.Add("/p", Entry{Query: "ext:go main", Mode: "Content", Used: now})          // Another mode doesn't supersede it
+	s.Add("/p", Entry{Query: "view", Mode: "Files", Match: "regex", Used: now})   // Nor does another match mode
+	s.Add("/p", Entry{Name: "ignored", Query: "named", Mode: "Files", Used: now}) // History entries have no name
+
+	// "mod" was superseded by "model", the blank query dropped, and running
+	// "model" again moved it to the front.
+	want := []string{"named", "view", "ext:go main", "ext:go", "model", "view"}
+	got := s.Recent("/p")
+	if !reflect.DeepEqual(queries(got), want) {
+		t.Errorf("Recent() = %q, want %q", queries(got), want)
+	}
+	if got[0].Name != "" {
+		t.Errorf("Add() kept the name %q", got[0].Name)
+	}
+	if got := s.Recent("/other"); got != nil {
+		t.Errorf("Recent() of another project = %q, want nothing", queries(got))
+	}
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompty", "history.json")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of a missing file failed: %v", err)
+	}
+	s.Add("/p", Entry{Query: "search", Mode: "Files", Used: now})
+	s.Add("/p", Entry{Query: `TODO\(`, Mode: "Content", Match: "regex", Used: now.Add(time.Minute)})
+	s.SaveQuery("/p", Entry{Name: "go sources", Query: "ext:go -path:vendor", Mode: "Files", Used: now})
+	s.SaveQuery("/p", Entry{Name: "docs", Query: "ext:md", Mode: "Files", Used: now})
+	s.SaveQuery("/p", Entry{Name: "go sources", Query: "ext:go", Mode: "Files", Used: now}) // Replaces the first one
+	s.Delete("/p", Entry{Name: "docs"})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of a saved file failed: %v", err)
+	}
+	wantRecent := []Entry{
+		{Query: `TODO\(`, Mode: "Content", Match: "regex", Used: now.Add(time.Minute)},
+		{Query: "search", Mode: "Files", Used: now},
+	}
+	if got := reopened.Recent("/p"); !reflect.DeepEqual(got, wantRecent) {
+		t.Errorf("Recent() after reopening = %+v, want %+v", got, wantRecent)
+	}
+	wantSaved := []Entry{{Name: "go sources", Query: "ext:go", Mode: "Files", Used: now}}
+	if got := reopened.Saved("/p"); !reflect.DeepEqual(got, wantSaved) {
+		t.Errorf("Saved() after reopening = %+v, want %+v", got, wantSaved)
+	}
+
+	reopened.Delete("/p", Entry{Query: "search", Mode: "Files"})
+	if got := queries(reopened.Recent("/p")); !reflect.DeepEqual(got, []string{`TODO\(`}) {
+		t.Errorf("Recent() after Delete = %q, want [TODO\\(]", got)
+	}
+}
@@ -0,0 +1,292 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"prompty/internal/glob"
+	"prompty/internal/xdg"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Names of the per-directory ignore files. Rules in a file apply to the
+// directory it lives in and everything below it.
+const (
+	GitignoreName     = ".gitignore"
+	PromptyignoreName = ".promptyignore"
+)
+
+// GlobalFile returns the path of the user-level ignore file, which applies to
+// every project: $XDG_CONFIG_HOME/prompty/ignore.
+func GlobalFile() string {
+	return filepath.Join(xdg.ConfigDir(), "ignore")
+}
+
+// IsIgnoreFile reports whether relPath names a file Load reads rules from.
+func IsIgnoreFile(relPath string) bool {
+	name := path.Base(relPath)
+	return name == GitignoreName || name == PromptyignoreName
+}
+
+// rule is a single pattern line of an ignore file.
+type rule struct {
+	base     string         // Directory (relative to the root, "" for the root) the rule is relative to
+	pattern  string         // The pattern without '!', leading and trailing '/'
+	negate   bool           // "!pattern": re-include what earlier rules ignored
+	dirOnly  bool           // "pattern/": only matches directories
+	anchored bool           // Pattern contains a '/': matched against the whole path below base
+	literal  bool           // Pattern has no glob syntax; compared as a plain string
+	suffix   string         // For "*.ext"-style patterns: the literal suffix to compare
+	re       *regexp.Regexp // Compiled pattern for everything else
+}
+
+// Matcher decides which paths of a project are ignored, using gitignore
+// syntax and semantics: rules are checked in the order they were added and
+// the last one that matches wins, so a later "!pattern" re-includes a path
+// ignored earlier. Files inside an ignored directory stay ignored.
+// A Matcher must not be modified once it is in use; matching is safe for
+// concurrent use.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns a Matcher without any rules, which ignores nothing.
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Load builds the Matcher for a project rooted at root whose files are paths
+// (slash-separated, relative to root). Rules are layered in this order, later
+// layers taking precedence over earlier ones:
+//
+//  1. every .gitignore among paths, shallower directories first,
+//  2. every .promptyignore among paths, shallower directories first,
+//  3. the user's global ignore file (see GlobalFile), if it exists.
+//
+// The .git directory is always ignored. Unreadable ignore files are skipped;
+// the returned error describes them, but the Matcher is usable regardless.
+func Load(root string, paths []string) (*Matcher, error) {
+	return load(root, paths, true)
+}
+
+// LoadTracked is like Load without the .gitignore layer. It is for the files
+// git tracks, which .gitignore doesn't hide: git keeps showing a tracked file
+// even when a pattern matches it.
+func LoadTracked(root string, paths []string) (*Matcher, error) {
+	return load(root, paths, false)
+}
+
+// load builds the Matcher for Load and LoadTracked; gitignore selects whether
+// the .gitignore layer is included.
+func load(root string, paths []string, gitignore bool) (*Matcher, error) {
+	m := New()
+	m.AddPatterns("", []string{".git/"})
+
+	var gitignores, promptyignores []string
+	for _, p := range paths {
+		switch path.Base(p) {
+		case GitignoreName:
+			if gitignore {
+				gitignores = append(gitignores, p)
+			}
+		case PromptyignoreName:
+			promptyignores = append(promptyignores, p)
+		}
+	}
+
+	var errs []error
+	for _, files := range [][]string{gitignores, promptyignores} {
+		sort.Slice(files, func(i, j int) bool {
+			di, dj := strings.Count(files[i], "/"), strings.Count(files[j], "/")
+			if di != dj {
+				return di < dj
+			}
+			return files[i] < files[j]
+		})
+		for _, p := range files {
+			base := path.Dir(p)
+			if base == "." {
+				base = ""
+			}
+			if err := m.AddFile(base, filepath.Join(root, filepath.FromSlash(p))); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if err := m.AddFile("", GlobalFile()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	return m, errors.Join(errs...)
+}
+
+// AddFile adds the rules read from the ignore file at filename, relative to
+// the directory base (slash-separated, "" for the project root).
+func (m *Matcher) AddFile(base, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	m.AddPatterns(base, lines)
+	return nil
+}
+
+// AddPatterns adds rules given as lines of an ignore file, relative to the
+// directory base. Blank lines, comments and invalid patterns are skipped.
+func (m *Matcher) AddPatterns(base string, lines []string) {
+	for _, line := range lines {
+		if r, ok := parseRule(base, line); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// Len returns the number of rules.
+func (m *Matcher) Len() int {
+	return len(m.rules)
+}
+
+// Ignored reports whether the path (slash-separated, relative to the project
+// root) is ignored, either by itself or because a directory containing it is.
+func (m *Matcher) Ignored(relPath string, isDir bool) bool {
+	if len(m.rules) == 0 {
+		return false
+	}
+	for i := 0; i < len(relPath); i++ {
+		if relPath[i] == '/' && m.match(relPath[:i], true) {
+			return true
+		}
+	}
+	return m.match(relPath, isDir)
+}
+
+// Filter returns the files among paths that are not ignored, and how many were.
+// It remembers the verdict for each directory, so large lists stay cheap.
+func (m *Matcher) Filter(paths []string) ([]string, int) {
+	if len(m.rules) == 0 {
+		return paths, 0
+	}
+	dirs := make(map[string]bool) // Directory -> ignored (itself or through a parent)
+	var dirIgnored func(dir string) bool
+	dirIgnored = func(dir string) bool {
+		if dir == "." {
+			return false
+		}
+		ignored, ok := dirs[dir]
+		if !ok {
+			ignored = dirIgnored(path.Dir(dir)) || m.match(dir, true)
+			dirs[dir] = ignored
+		}
+		return ignored
+	}
+
+	kept := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if dirIgnored(path.Dir(p)) || m.match(p, false) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, len(paths) - len(kept)
+}
+
+// match applies the rules to a single path, ignoring its parent directories.
+func (m *Matcher) match(relPath string, isDir bool) bool {
+	ignored := false
+	for i := range m.rules {
+		r := &m.rules[i]
+		if r.negate == !ignored {
+			continue // This rule can't change the verdict
+		}
+		if r.matches(relPath, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matches reports whether the rule's pattern matches relPath.
+func (r *rule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(relPath, r.base+"/") {
+			return false
+		}
+		relPath = relPath[len(r.base)+1:]
+	}
+	subject := relPath
+	if !r.anchored {
+		subject = path.Base(relPath)
+	}
+	switch {
+	case r.literal:
+		return subject == r.pattern
+	case r.suffix != "":
+		return strings.HasSuffix(subject, r.suffix)
+	default:
+		return r.re.MatchString(subject)
+	}
+}
+
+// parseRule parses one line of an ignore file.
+func parseRule(base, line string) (rule, bool) {
+	// Trailing spaces are ignored unless escaped with a backslash.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	r.pattern = line
+
+	switch {
+	case !glob.HasMeta(line):
+		r.literal = true
+	case !r.anchored && len(line) > 1 && line[0] == '*' && !glob.HasMeta(line[1:]):
+		r.suffix = line[1:]
+	default:
+		re, err := glob.Compile(line)
+		if err != nil {
+			return rule{}, false
+		}
+		r.re = re
+	}
+	return r, true
+}
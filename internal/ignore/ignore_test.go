@@ -0,0 +1,170 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	m := New()
+	m.AddPatterns("", []string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/todo.txt",
+		"docs/*.pdf",
+		`\#literal`,
+		"trailing   ",
+	})
+	m.AddPatterns("web", []string{"dist", "*.map"})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true}, // No '/' in the pattern: matches at any depth
+		{"keep.log", false, false},    // Re-included by a later rule
+		{"build", true, true},
+		{"build", false, false}, // "build/" only matches directories
+		{"build/out/a.o", false, true},
+		{"src/build/a.o", false, true},
+		{"todo.txt", false, true},
+		{"src/todo.txt", false, false}, // Anchored to the root
+		{"docs/guide.pdf", false, true},
+		{"docs/sub/guide.pdf", false, false}, // '*' doesn't cross '/'
+		{"#literal", false, true},
+		{"trailing", false, true},
+		{"web/dist/app.js", false, true}, // Rules of web/ apply below web/
+		{"web/app.js.map", false, true},
+		{"dist/app.js", false, false}, // ... but not elsewhere
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+	if got := m.Len(); got != 9 {
+		t.Errorf("Len() = %d, want 9 (blank lines and comments skipped)", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	m := New()
+	m.AddPatterns("", []string{"vendor/", "*.tmp", "!vendor/keep.go"})
+	paths := []string{"main.go", "vendor/a.go", "vendor/keep.go", "x.tmp", "pkg/y.go"}
+	got, ignored := m.Filter(paths)
+	// A file inside an ignored directory stays ignored, whatever later rules say.
+	want := []string{"main.go", "pkg/y.go"}
+	if !slices.Equal(got, want) || ignored != 3 {
+		t.Errorf("Filter() = %q, %d ignored; want %q, 3 ignored", got, ignored, want)
+	}
+	for _, p := range paths {
+		if m.Ignored(p, false) == slices.Contains(got, p) {
+			t.Errorf("Filter and Ignored disagree about %q", p)
+		}
+	}
+
+	if got, ignored := New().Filter(paths); !slices.Equal(got, paths) || ignored != 0 {
+		t.Errorf("Filter() without rules = %q, %d ignored; want every path", got, ignored)
+	}
+}
+
+// writeFiles creates files under root with the given contents.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, text := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	writeFiles(t, config, map[string]string{"prompty/ignore": "*.secret\n!dist/public.js\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":         "dist/\n*.gen.go\n",
+		"sub/.gitignore":     "!x.gen.go\nlocal.txt\n",
+		".promptyignore":     "!dist/\ndist/*.js\nfixtures/\n",
+		"sub/.promptyignore": "notes.md\n",
+	})
+	paths := []string{".gitignore", "sub/.gitignore", ".promptyignore", "sub/.promptyignore"}
+
+	m, err := Load(root, paths)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	tracked, err := LoadTracked(root, paths)
+	if err != nil {
+		t.Fatalf("LoadTracked() failed: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		want        bool // With every layer
+		wantTracked bool // Without .gitignore
+	}{
+		{".git/config", true, true},       // Always ignored
+		{"a.gen.go", true, false},         // .gitignore
+		{"sub/x.gen.go", false, false},    // A deeper .gitignore wins over a shallower one
+		{"sub/local.txt", true, false},    // Rules of sub/.gitignore apply below sub/
+		{"local.txt", false, false},       // ... but not elsewhere
+		{"dist/app.css", false, false},    // .promptyignore re-includes what .gitignore hides
+		{"dist/app.js", true, true},       // ... and can hide part of it again
+		{"dist/public.js", false, false},  // The global file wins over both
+		{"fixtures/big.json", true, true}, // .promptyignore
+		{"sub/notes.md", true, true},      // A nested .promptyignore
+		{"keys/prod.secret", true, true},  // The global file
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, false); got != tt.want {
+			t.Errorf("Load: Ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+		if got := tracked.Ignored(tt.path, false); got != tt.wantTracked {
+			t.Errorf("LoadTracked: Ignored(%q) = %v, want %v", tt.path, got, tt.wantTracked)
+		}
+	}
+}
+
+func TestLoadUnreadableFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No global file
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".promptyignore": "*.tmp\n"})
+
+	// A listed ignore file that is gone is reported, but the rest still apply.
+	m, err := Load(root, []string{"missing/.gitignore", ".promptyignore"})
+	if err == nil {
+		t.Error("Load() with a missing ignore file succeeded, want an error")
+	}
+	if m == nil || !m.Ignored("a.tmp", false) {
+		t.Error("Load() with a missing ignore file dropped the readable rules")
+	}
+}
+
+func TestIsIgnoreFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		".gitignore":         true,
+		"sub/.promptyignore": true,
+		"gitignore":          false,
+		"sub/.gitignore.bak": false,
+	} {
+		if got := IsIgnoreFile(path); got != want {
+			t.Errorf("IsIgnoreFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitSubmoduleMode is the index mode git records for a submodule (a "gitlink").
+const gitSubmoduleMode = "160000"
+
+// maxSubmoduleDepth bounds how deeply nested submodules are followed.
+const maxSubmoduleDepth = 8
+
+// isGitWorkTree reports whether root is inside a git work tree and git is installed.
+func isGitWorkTree(root string) bool {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false
+	}
+	out, err := runGit(root, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// listGitFiles lists the files git knows about under root: tracked files plus
+// untracked files that .gitignore doesn't exclude, which are also returned
+// separately. Initialised submodules are listed recursively, their paths
+// prefixed with the submodule's path.
+func listGitFiles(root string, logger *slog.Logger) (paths, untracked []string, err error) {
+	return listGitDir(root, "", 0, logger)
+}
+
+// listGitDir lists the work tree at dir, prefixing every path with prefix.
+func listGitDir(dir, prefix string, depth int, logger *slog.Logger) (paths, untracked []string, err error) {
+	// --stage prints "<mode> <object> <stage>\t<path>", which is how submodules are told apart.
+	staged, err := runGit(dir, "ls-files", "-z", "--stage")
+	if err != nil {
+		return nil, nil, err
+	}
+	var submodules []string
+	for _, record := range splitNUL(staged) {
+		meta, p, ok := strings.Cut(record, "\t")
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(meta, gitSubmoduleMode+" ") {
+			submodules = append(submodules, p)
+			continue
+		}
+		paths = append(paths, prefix+p)
+	}
+	// A file with unmerged changes has one record per stage.
+	paths = dedupeSorted(paths)
+
+	others, err := runGit(dir, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range splitNUL(others) {
+		paths = append(paths, prefix+p)
+		untracked = append(untracked, prefix+p)
+	}
+
+	for _, sub := range submodules {
+		subDir := filepath.Join(dir, filepath.FromSlash(sub))
+		if _, err := os.Stat(filepath.Join(subDir, ".git")); err != nil {
+			logger.Debug("Skipping uninitialised submodule", "path", prefix+sub)
+			continue
+		}
+		if depth >= maxSubmoduleDepth {
+			logger.Warn("Submodules nested too deeply, not listing", "path", prefix+sub)
+			continue
+		}
+		subPaths, subUntracked, err := listGitDir(subDir, prefix+sub+"/", depth+1, logger)
+		if err != nil {
+			// One broken submodule shouldn't hide the rest of the project.
+			logger.Warn("Failed to list submodule", "path", prefix+sub, "err", err)
+			continue
+		}
+		paths = append(paths, subPaths...)
+		untracked = append(untracked, subUntracked...)
+	}
+	return paths, untracked, nil
+}
+
+// countGitIgnored returns how many untracked paths in the work tree at root
+// are ignored by git. A wholly ignored directory such as node_modules/ counts
+// once, so git doesn't have to list everything inside it. Errors count as
+// zero; the number is only informative.
+func countGitIgnored(root string) int {
+	out, err := runGit(root, "ls-files", "-z", "--others", "--ignored", "--exclude-standard", "--directory", "--no-empty-directory")
+	if err != nil {
+		return 0
+	}
+	return len(splitNUL(out))
+}
+
+// runGit runs a git command in dir and returns its standard output.
+func runGit(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w (stderr: %s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// splitNUL splits NUL-terminated git output (from -z) into cleaned paths.
+// Unlike line output, it needs no unquoting of unusual file names.
+func splitNUL(output string) []string {
+	var paths []string
+	for _, p := range strings.Split(output, "\x00") {
+		if p != "" {
+			paths = append(paths, path.Clean(p))
+		}
+	}
+	return paths
+}
+
+// dedupeSorted removes adjacent duplicates from a sorted slice in place.
+func dedupeSorted(paths []string) []string {
+	out := paths[:0]
+	for i, p := range paths {
+		if i == 0 || p != paths[i-1] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,492 @@
+package index
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"prompty/internal/ignore"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWatchUnsupported is returned by Watch on platforms without a filesystem watcher.
+var ErrWatchUnsupported = errors.New("filesystem watching is not supported on this platform")
+
+// Entry describes a single file known to the index.
+type Entry struct {
+	Path    string    // Slash-separated path relative to the index root
+	Size    int64     // Size in bytes, as of the last stat
+	ModTime time.Time // Modification time, as of the last stat
+	// Untracked is set for files in a git work tree that haven't been committed
+	// (or staged) yet, including ones created while the index is watched.
+	Untracked bool
+}
+
+// Index is an in-memory list of the files under a root directory.
+// It is built once with Build and then kept current by Watch, so searches,
+// tagging and content loading never have to walk the tree themselves.
+// All methods are safe for concurrent use.
+type Index struct {
+	root   string       // Absolute root directory; entry paths are relative to it
+	logger *slog.Logger // Where the index reports builds, watch problems and the like
+
+	mu      sync.RWMutex
+	entries map[string]Entry // All indexed files, keyed by relative path
+	paths   []string         // Sorted snapshot of the keys of entries; nil when stale
+	rules   *ignore.Matcher  // Ignore rules loaded by the last Build
+	// trackedRules are the rules for files git tracks, without the .gitignore
+	// layer (see ignore.LoadTracked); outside git they are the same as rules.
+	trackedRules *ignore.Matcher
+	ignored      int  // Number of files left out by the ignore rules at the last Build
+	git          bool // Whether the last Build listed files with git
+
+	changes chan struct{} // Signalled (coalesced) whenever entries change
+	watcher *watcher      // Filesystem watcher, nil until Watch is called
+}
+
+// New creates an empty index rooted at root that logs to logger. Call Build to populate it.
+func New(root string, logger *slog.Logger) *Index {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Index{
+		root:         root,
+		logger:       logger,
+		entries:      make(map[string]Entry),
+		rules:        ignore.New(),
+		trackedRules: ignore.New(),
+		changes:      make(chan struct{}, 1),
+	}
+}
+
+// Root returns the absolute directory the index was created for.
+func (ix *Index) Root() string {
+	return ix.root
+}
+
+// Abs returns the absolute filesystem path of an indexed (relative) path.
+func (ix *Index) Abs(relPath string) string {
+	return filepath.Join(ix.root, filepath.FromSlash(relPath))
+}
+
+// Build lists every file under the root and replaces the index contents.
+// It prefers 'git ls-files' inside a work tree, then 'rg --files', and finally
+// walks the directory tree itself if neither tool is available. Whatever the
+// source, the ignore rules (.gitignore, .promptyignore and the global ignore
+// file, see ignore.Load) are then applied and reloaded from disk. Files git
+// tracks are shown even if .gitignore matches them, as git does, so only the
+// .promptyignore and global rules apply to them.
+func (ix *Index) Build() error {
+	started := time.Now()
+	list, err := listFiles(ix.root, ix.logger)
+	if err != nil {
+		return err
+	}
+
+	rules, err := ignore.Load(ix.root, list.paths)
+	if err != nil {
+		// Not fatal: the rules that could be read still apply.
+		ix.logger.Warn("Some ignore files could not be read", "err", err)
+	}
+	trackedRules := rules
+	var paths []string
+	var ignored int
+	if list.git {
+		// The ignore files were just read; reading them again without .gitignore
+		// can only fail the same way, which was logged above.
+		trackedRules, _ = ignore.LoadTracked(ix.root, list.paths)
+		var tracked, untracked []string
+		for _, p := range list.paths {
+			if list.untracked[p] {
+				untracked = append(untracked, p)
+			} else {
+				tracked = append(tracked, p)
+			}
+		}
+		keptTracked, ignoredTracked := trackedRules.Filter(tracked)
+		keptUntracked, ignoredUntracked := rules.Filter(untracked)
+		paths = append(keptTracked, keptUntracked...)
+		// git ls-files never lists untracked files matched by .gitignore; count them too.
+		ignored = ignoredTracked + ignoredUntracked + countGitIgnored(ix.root)
+	} else {
+		paths, ignored = rules.Filter(list.paths)
+	}
+
+	entries := make(map[string]Entry, len(paths))
+	for _, p := range paths {
+		if entry, ok := ix.stat(p); ok {
+			entry.Untracked = list.untracked[p]
+			entries[p] = entry
+		}
+	}
+
+	ix.mu.Lock()
+	ix.git = list.git
+	ix.entries = entries
+	ix.paths = nil
+	ix.rules = rules
+	ix.trackedRules = trackedRules
+	ix.ignored = ignored
+	ix.mu.Unlock()
+
+	ix.logger.Info("Built file index", "files", len(entries), "untracked", len(list.untracked), "ignored", ignored, "rules", rules.Len(), "root", ix.root, "took", time.Since(started))
+	ix.notify()
+	return nil
+}
+
+// Watch starts keeping the index current with filesystem notifications.
+// It returns ErrWatchUnsupported on platforms without a watcher implementation.
+func (ix *Index) Watch() error {
+	ix.mu.Lock()
+	if ix.watcher != nil {
+		ix.mu.Unlock()
+		return nil
+	}
+	ix.mu.Unlock()
+
+	w, err := newWatcher(ix)
+	if err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	ix.watcher = w
+	ix.mu.Unlock()
+	return nil
+}
+
+// Close stops the filesystem watcher, if any.
+func (ix *Index) Close() error {
+	ix.mu.Lock()
+	w := ix.watcher
+	ix.watcher = nil
+	ix.mu.Unlock()
+
+	if w == nil {
+		return nil
+	}
+	return w.close()
+}
+
+// Changes returns a channel that receives a value whenever the set of indexed
+// files changes. Notifications are coalesced: a burst of changes may be
+// reported by a single receive.
+func (ix *Index) Changes() <-chan struct{} {
+	return ix.changes
+}
+
+// Len returns the number of indexed files.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.entries)
+}
+
+// Ignored returns how many files the ignore rules left out, as of the last Build.
+// Directories that git ignores as a whole count as one.
+func (ix *Index) Ignored() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.ignored
+}
+
+// IsIgnored reports whether the ignore rules exclude a relative path. For a
+// file git tracks, .gitignore is left out, as in Build.
+func (ix *Index) IsIgnored(relPath string, isDir bool) bool {
+	ix.mu.RLock()
+	rules := ix.rules
+	if entry, ok := ix.entries[relPath]; ok && ix.git && !entry.Untracked {
+		rules = ix.trackedRules
+	}
+	ix.mu.RUnlock()
+	return rules.Ignored(relPath, isDir)
+}
+
+// Paths returns every indexed path in sorted order.
+// The returned slice is shared and must not be modified.
+func (ix *Index) Paths() []string {
+	ix.mu.RLock()
+	paths := ix.paths
+	ix.mu.RUnlock()
+	if paths != nil {
+		return paths
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.paths == nil {
+		ix.paths = make([]string, 0, len(ix.entries))
+		for p := range ix.entries {
+			ix.paths = append(ix.paths, p)
+		}
+		sort.Strings(ix.paths)
+	}
+	return ix.paths
+}
+
+// Lookup returns the entry for a relative path, if it is indexed.
+func (ix *Index) Lookup(relPath string) (Entry, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	entry, ok := ix.entries[relPath]
+	return entry, ok
+}
+
+// stat builds an Entry for a relative path. Directories and files that
+// vanished since they were listed are skipped.
+func (ix *Index) stat(relPath string) (Entry, bool) {
+	info, err := os.Stat(ix.Abs(relPath))
+	if err != nil || info.IsDir() {
+		return Entry{}, false
+	}
+	return Entry{Path: relPath, Size: info.Size(), ModTime: info.ModTime()}, true
+}
+
+// update adds or refreshes a single file. It reports whether the index changed.
+func (ix *Index) update(relPath string) bool {
+	entry, ok := ix.stat(relPath)
+	if !ok || ix.IsIgnored(relPath, false) {
+		return ix.remove(relPath)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	old, existed := ix.entries[relPath]
+	if existed {
+		entry.Untracked = old.Untracked
+	} else {
+		// A file git hasn't listed can only be new.
+		entry.Untracked = ix.git
+	}
+	ix.entries[relPath] = entry
+	if !existed {
+		ix.paths = nil
+	}
+	return !existed || old != entry
+}
+
+// remove drops a single file. It reports whether the index changed.
+func (ix *Index) remove(relPath string) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if _, ok := ix.entries[relPath]; !ok {
+		return false
+	}
+	delete(ix.entries, relPath)
+	ix.paths = nil
+	return true
+}
+
+// removeDir drops every file under a directory. It reports whether the index changed.
+func (ix *Index) removeDir(relDir string) bool {
+	prefix := relDir + "/"
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	changed := false
+	for p := range ix.entries {
+		if strings.HasPrefix(p, prefix) {
+			delete(ix.entries, p)
+			changed = true
+		}
+	}
+	if changed {
+		ix.paths = nil
+	}
+	return changed
+}
+
+// renameDir moves every file under oldDir to newDir, keeping their metadata.
+// It reports whether the index changed.
+func (ix *Index) renameDir(oldDir, newDir string) bool {
+	prefix := oldDir + "/"
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	changed := false
+	for p, entry := range ix.entries {
+		if strings.HasPrefix(p, prefix) {
+			delete(ix.entries, p)
+			entry.Path = path.Join(newDir, strings.TrimPrefix(p, prefix))
+			if !ix.rules.Ignored(entry.Path, false) { // Moving into an ignored directory drops the files
+				ix.entries[entry.Path] = entry
+			}
+			changed = true
+		}
+	}
+	if changed {
+		ix.paths = nil
+	}
+	return changed
+}
+
+// addDir indexes every file under a directory that appeared after Build,
+// e.g. one that was created or moved into the tree. onDir, if set, is called
+// for the directory itself and every subdirectory. It reports whether the index changed.
+func (ix *Index) addDir(relDir string, onDir func(relDir string)) bool {
+	changed := false
+	walkErr := filepath.WalkDir(ix.Abs(relDir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Entries can disappear while we walk; skip them
+		}
+		if d.IsDir() {
+			rel, ok := ix.rel(p)
+			if d.Name() == ".git" || (ok && ix.IsIgnored(rel, true)) {
+				return filepath.SkipDir
+			}
+			if ok && onDir != nil {
+				onDir(rel)
+			}
+			return nil
+		}
+		if rel, ok := ix.rel(p); ok && ix.update(rel) {
+			changed = true
+		}
+		return nil
+	})
+	if walkErr != nil {
+		ix.logger.Warn("Error walking new directory", "dir", relDir, "err", walkErr)
+	}
+	return changed
+}
+
+// rel converts an absolute path under the root to a slash-separated relative path.
+func (ix *Index) rel(absPath string) (string, bool) {
+	rel, err := filepath.Rel(ix.root, absPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// dirs returns the directories to watch: the root, every directory under it
+// the ignore rules don't exclude, empty ones included so files created there
+// are noticed, and every directory that contains an indexed file, as a file
+// git tracks can live in a directory .gitignore matches.
+func (ix *Index) dirs() []string {
+	seen := map[string]bool{".": true}
+	walkErr := filepath.WalkDir(ix.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil // Unreadable directories can't be watched either; skip them
+		}
+		rel, ok := ix.rel(p)
+		if !ok {
+			return nil // The root itself
+		}
+		if d.Name() == ".git" || ix.IsIgnored(rel, true) {
+			return filepath.SkipDir
+		}
+		seen[rel] = true
+		return nil
+	})
+	if walkErr != nil {
+		ix.logger.Warn("Error walking directories to watch", "err", walkErr)
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	for p := range ix.entries {
+		for dir := path.Dir(p); !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+		}
+	}
+	dirs := make([]string, 0, len(seen))
+	for dir := range seen {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// notify signals Changes without blocking; a pending signal already covers this change.
+func (ix *Index) notify() {
+	select {
+	case ix.changes <- struct{}{}:
+	default:
+	}
+}
+
+// listing is the raw file list of a project, before ignore rules are applied.
+type listing struct {
+	paths     []string        // Slash-separated paths relative to the root
+	untracked map[string]bool // Files git doesn't track yet; only set when git is true
+	git       bool            // Whether git produced the list
+}
+
+// listFiles lists the files under root. Inside a git work tree it asks git
+// (tracked plus untracked-but-not-ignored files, submodules included);
+// otherwise it uses 'rg --files', or walks the tree itself if rg is missing.
+func listFiles(root string, logger *slog.Logger) (listing, error) {
+	if isGitWorkTree(root) {
+		logger.Debug("Listing files with git ls-files")
+		paths, untracked, err := listGitFiles(root, logger)
+		if err != nil {
+			return listing{}, fmt.Errorf("failed to list files: %w", err)
+		}
+		l := listing{paths: paths, untracked: make(map[string]bool, len(untracked)), git: true}
+		for _, p := range untracked {
+			l.untracked[p] = true
+		}
+		return l, nil
+	}
+
+	if _, err := exec.LookPath("rg"); err != nil {
+		logger.Info("Neither git nor rg is usable, walking the tree directly", "root", root)
+		paths, err := walkFiles(root)
+		return listing{paths: paths}, err
+	}
+
+	logger.Debug("Listing files with rg --files")
+	// --no-ignore: the ignore rules are applied by Build, the same way for every
+	// source, which also lets it count what they leave out.
+	cmd := exec.Command("rg", "--files", "--hidden", "--no-ignore", ".", "--max-depth", "100")
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return listing{}, fmt.Errorf("failed to list files: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	var paths []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		p := strings.TrimSpace(line)
+		if p != "" {
+			paths = append(paths, filepath.ToSlash(filepath.Clean(p)))
+		}
+	}
+	return listing{paths: paths}, nil
+}
+
+// walkFiles lists files under root without external tools, skipping .git directories.
+func walkFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr == nil {
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	return paths, nil
+}
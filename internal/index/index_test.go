@@ -0,0 +1,119 @@
+package index
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"prompty/internal/logging"
+	"slices"
+	"testing"
+)
+
+// writeFile creates a file under root, along with its parent directories.
+func writeFile(t *testing.T, root, name, text string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "internal/a/a.go", "package a\n")
+	writeFile(t, root, "README.md", "# readme\n")
+
+	ix := New(root, logging.Discard())
+	if err := ix.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	want := []string{"README.md", "internal/a/a.go", "main.go"}
+	if got := ix.Paths(); !slices.Equal(got, want) {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+	entry, ok := ix.Lookup("main.go")
+	if !ok || entry.Size != int64(len("package main\n")) {
+		t.Errorf("Lookup(main.go) = %+v, %v; want its size", entry, ok)
+	}
+	if _, ok := ix.Lookup("internal/a"); ok {
+		t.Error("Lookup() found a directory, want only files")
+	}
+
+	// Build signals Changes so the UI picks up the first listing.
+	select {
+	case <-ix.Changes():
+	default:
+		t.Error("Build() did not signal Changes")
+	}
+}
+
+func TestBuildIgnores(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No global ignore file
+	root := t.TempDir()
+	writeFile(t, root, ".promptyignore", "*.log\nbuild/\n")
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "debug.log", "...\n")
+	writeFile(t, root, "build/out.bin", "\x00\n")
+
+	ix := New(root, logging.Discard())
+	if err := ix.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	want := []string{".promptyignore", "main.go"}
+	if got := ix.Paths(); !slices.Equal(got, want) {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+	if got := ix.Ignored(); got != 2 {
+		t.Errorf("Ignored() = %d, want 2", got)
+	}
+	if !ix.IsIgnored("build", true) || ix.IsIgnored("main.go", false) {
+		t.Error("IsIgnored() disagrees with .promptyignore")
+	}
+}
+
+func TestBuildKeepsTrackedIgnoredFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.gen.go\nnode_modules/\n")
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "tracked.gen.go", "package main\n")
+	writeFile(t, root, "untracked.gen.go", "package main\n")
+	writeFile(t, root, "node_modules/a/index.js", "\n")
+	writeFile(t, root, "node_modules/b/index.js", "\n")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", ".gitignore", "main.go"},
+		{"add", "-f", "tracked.gen.go"}, // Tracked despite matching .gitignore
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	ix := New(root, logging.Discard())
+	if err := ix.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	// git keeps tracking a file that matches .gitignore, and so does the index.
+	want := []string{".gitignore", "main.go", "tracked.gen.go"}
+	if got := ix.Paths(); !slices.Equal(got, want) {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+	if ix.IsIgnored("tracked.gen.go", false) || !ix.IsIgnored("untracked.gen.go", false) {
+		t.Error("IsIgnored() treats tracked and untracked files alike")
+	}
+	// untracked.gen.go, and node_modules/ as a whole.
+	if got := ix.Ignored(); got != 2 {
+		t.Errorf("Ignored() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,124 @@
+package index
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Scope narrows searches down to the files of a change, as git sees it.
+type Scope int
+
+const (
+	ScopeAll      Scope = iota // 0: Every indexed file
+	ScopeModified              // 1: Changed in the working tree but not staged, plus untracked files
+	ScopeStaged                // 2: Staged for the next commit
+	ScopeBranch                // 3: Changed on this branch since it forked from the base branch
+	ScopeRecent                // 4: Touched by the last few commits
+)
+
+// DefaultRecentCommits is how many commits ScopeRecent looks back by default.
+const DefaultRecentCommits = 5
+
+// ScopeOptions holds the settings of the scopes that need any.
+type ScopeOptions struct {
+	Base    string // Base branch for ScopeBranch; empty picks origin's default branch, main or master
+	Commits int    // Number of commits for ScopeRecent
+}
+
+// Next returns the scope that follows s when cycling through them.
+func (s Scope) Next() Scope {
+	return (s + 1) % (ScopeRecent + 1)
+}
+
+// Label returns a short description of the scope, e.g. "staged" or
+// "main...HEAD". It names the base branch as resolved by the last ScopeFiles
+// call, which is what base holds.
+func (s Scope) Label(opts ScopeOptions) string {
+	switch s {
+	case ScopeModified:
+		return "modified"
+	case ScopeStaged:
+		return "staged"
+	case ScopeBranch:
+		if opts.Base == "" {
+			return "branch"
+		}
+		return opts.Base + "...HEAD"
+	case ScopeRecent:
+		if opts.Commits <= 1 {
+			return "last commit"
+		}
+		return "last " + strconv.Itoa(opts.Commits) + " commits"
+	default:
+		return "all files"
+	}
+}
+
+// ScopeFiles asks git for the files in scope, relative to the index root and
+// limited to the indexed ones, so deleted and ignored files are left out. For
+// ScopeBranch it also returns the base branch it compared against, which is
+// opts.Base unless that was empty. It fails outside a git work tree.
+func (ix *Index) ScopeFiles(scope Scope, opts ScopeOptions) (files []string, base string, err error) {
+	if scope == ScopeAll {
+		return ix.Paths(), "", nil
+	}
+	if !isGitWorkTree(ix.root) {
+		return nil, "", fmt.Errorf("%s is not inside a git work tree", ix.root)
+	}
+
+	// --relative makes paths relative to the root (rather than the top of the
+	// repository) and leaves out changes outside it; -z avoids quoted names.
+	var out string
+	switch scope {
+	case ScopeModified:
+		out, err = runGit(ix.root, "diff", "--name-only", "-z", "--relative")
+		if err == nil {
+			var others string
+			others, err = runGit(ix.root, "ls-files", "-z", "--others", "--exclude-standard")
+			out += others
+		}
+	case ScopeStaged:
+		out, err = runGit(ix.root, "diff", "--cached", "--name-only", "-z", "--relative")
+	case ScopeBranch:
+		base = opts.Base
+		if base == "" {
+			if base, err = defaultBaseBranch(ix.root); err != nil {
+				return nil, "", err
+			}
+		}
+		out, err = runGit(ix.root, "diff", "--name-only", "-z", "--relative", base+"...HEAD")
+	case ScopeRecent:
+		commits := max(opts.Commits, 1)
+		out, err = runGit(ix.root, "log", "-n", strconv.Itoa(commits), "--name-only", "--format=", "-z", "--relative")
+	}
+	if err != nil {
+		return nil, base, err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range splitNUL(out) {
+		if _, indexed := ix.Lookup(p); indexed && !seen[p] {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+	ix.logger.Debug("Listed scope files", "scope", scope.Label(ScopeOptions{Base: base, Commits: opts.Commits}), "files", len(files))
+	return files, base, nil
+}
+
+// defaultBaseBranch guesses the branch work is merged into: the default
+// branch of origin if it is known, otherwise main or master.
+func defaultBaseBranch(root string) (string, error) {
+	if out, err := runGit(root, "rev-parse", "--abbrev-ref", "origin/HEAD"); err == nil {
+		if ref := strings.TrimSpace(out); ref != "" && ref != "origin/HEAD" {
+			return ref, nil
+		}
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := runGit(root, "rev-parse", "--verify", "--quiet", branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("no base branch found; pass one with -base")
+}
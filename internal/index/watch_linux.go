@@ -0,0 +1,271 @@
+//go:build linux
+
+package index
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"prompty/internal/ignore"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask is the set of inotify events the watcher subscribes to for each directory.
+const watchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_CLOSE_WRITE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ONLYDIR
+
+// watcher keeps an Index current using Linux inotify.
+// inotify is not recursive, so every directory that isn't ignored gets its own watch.
+type watcher struct {
+	ix   *Index
+	fd   int      // inotify file descriptor
+	file *os.File // fd wrapped for the runtime poller, so close unblocks reads
+
+	mu   sync.Mutex
+	dirs map[int]string // Watch descriptor -> relative directory
+	wds  map[string]int // Relative directory -> watch descriptor
+
+	limitLogged bool // Whether running out of inotify watches has been reported
+}
+
+// pendingMove is the first half of a rename, waiting for its IN_MOVED_TO.
+type pendingMove struct {
+	path  string
+	isDir bool
+}
+
+// newWatcher starts watching every directory the ignore rules don't exclude
+// (see Index.dirs), including empty ones.
+func newWatcher(ix *Index) (*watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise inotify: %w", err)
+	}
+
+	w := &watcher{
+		ix:   ix,
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int]string),
+		wds:  make(map[string]int),
+	}
+	for _, dir := range ix.dirs() {
+		w.add(dir)
+	}
+	ix.logger.Info("Watching for file changes", "dirs", len(w.wds), "root", ix.root)
+
+	go w.run()
+	return w, nil
+}
+
+// close stops the watcher; the reading goroutine exits once the file is closed.
+func (w *watcher) close() error {
+	return w.file.Close()
+}
+
+// add starts watching a relative directory.
+func (w *watcher) add(relDir string) {
+	wd, err := unix.InotifyAddWatch(w.fd, w.ix.Abs(relDir), watchMask)
+	if err != nil {
+		if errors.Is(err, unix.ENOSPC) {
+			if !w.limitLogged {
+				w.ix.logger.Warn("inotify watch limit reached; changes in some directories won't be noticed. Raise fs.inotify.max_user_watches to fix this.")
+				w.limitLogged = true
+			}
+			return
+		}
+		w.ix.logger.Warn("Failed to watch directory", "dir", relDir, "err", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirs[wd] = relDir
+	w.wds[relDir] = wd
+}
+
+// forget drops the bookkeeping for a watch the kernel has already removed.
+func (w *watcher) forget(wd int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if dir, ok := w.dirs[wd]; ok {
+		delete(w.wds, dir)
+		delete(w.dirs, wd)
+	}
+}
+
+// removeTree stops watching a directory and everything below it.
+func (w *watcher) removeTree(relDir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir, wd := range w.wds {
+		if dir == relDir || strings.HasPrefix(dir, relDir+"/") {
+			unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, dir)
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+// renameTree updates the recorded paths of watches under a moved directory.
+// The kernel keeps the watches themselves; only our path bookkeeping is stale.
+func (w *watcher) renameTree(oldDir, newDir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir, wd := range w.wds {
+		if dir == oldDir || strings.HasPrefix(dir, oldDir+"/") {
+			moved := newDir + strings.TrimPrefix(dir, oldDir)
+			delete(w.wds, dir)
+			w.wds[moved] = wd
+			w.dirs[wd] = moved
+		}
+	}
+}
+
+// dir returns the relative directory for a watch descriptor.
+func (w *watcher) dir(wd int) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dir, ok := w.dirs[wd]
+	return dir, ok
+}
+
+// run reads inotify events until the watcher is closed.
+func (w *watcher) run() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.ix.logger.Warn("inotify read failed, no longer watching", "err", err)
+			}
+			return
+		}
+		if w.handle(buf[:n]) {
+			w.ix.notify()
+		}
+	}
+}
+
+// handle applies one batch of raw inotify events to the index.
+// It reports whether the index changed.
+func (w *watcher) handle(buf []byte) bool {
+	changed := false
+	rulesChanged := false // Whether a .gitignore or .promptyignore was touched
+	moves := make(map[uint32]pendingMove)
+
+	for len(buf) >= unix.SizeofInotifyEvent {
+		wd := int(int32(binary.NativeEndian.Uint32(buf[0:4])))
+		mask := binary.NativeEndian.Uint32(buf[4:8])
+		cookie := binary.NativeEndian.Uint32(buf[8:12])
+		nameLen := int(binary.NativeEndian.Uint32(buf[12:16]))
+		if len(buf) < unix.SizeofInotifyEvent+nameLen {
+			break
+		}
+		name := strings.TrimRight(string(buf[unix.SizeofInotifyEvent:unix.SizeofInotifyEvent+nameLen]), "\x00")
+		buf = buf[unix.SizeofInotifyEvent+nameLen:]
+
+		if mask&unix.IN_Q_OVERFLOW != 0 {
+			// Events were dropped, so the only safe option is to start over.
+			w.ix.logger.Warn("inotify queue overflowed, rebuilding index")
+			w.rebuild()
+			continue
+		}
+		if mask&unix.IN_IGNORED != 0 {
+			w.forget(wd)
+			continue
+		}
+
+		dir, ok := w.dir(wd)
+		if !ok || name == "" || name == ".git" {
+			continue
+		}
+		rel := path.Join(dir, name)
+		isDir := mask&unix.IN_ISDIR != 0
+		if ignore.IsIgnoreFile(rel) {
+			rulesChanged = true
+		}
+
+		switch {
+		case mask&unix.IN_MOVED_FROM != 0:
+			moves[cookie] = pendingMove{path: rel, isDir: isDir}
+
+		case mask&unix.IN_MOVED_TO != 0:
+			from, paired := moves[cookie]
+			delete(moves, cookie)
+			switch {
+			case paired && isDir:
+				w.renameTree(from.path, rel)
+				changed = w.ix.renameDir(from.path, rel) || changed
+			case paired:
+				changed = w.ix.remove(from.path) || changed
+				changed = w.ix.update(rel) || changed
+			case isDir:
+				changed = w.ix.addDir(rel, w.add) || changed
+			default:
+				changed = w.ix.update(rel) || changed
+			}
+
+		case mask&unix.IN_CREATE != 0:
+			if isDir {
+				// Watches the new directory and its subdirectories unless they are
+				// ignored, even while they are still empty.
+				changed = w.ix.addDir(rel, w.add) || changed
+			} else {
+				changed = w.ix.update(rel) || changed
+			}
+
+		case mask&unix.IN_CLOSE_WRITE != 0:
+			changed = w.ix.update(rel) || changed
+
+		case mask&unix.IN_DELETE != 0:
+			if isDir {
+				changed = w.ix.removeDir(rel) || changed
+			} else {
+				changed = w.ix.remove(rel) || changed
+			}
+		}
+	}
+
+	// Moves without a matching IN_MOVED_TO left the tree.
+	for _, move := range moves {
+		if move.isDir {
+			w.removeTree(move.path)
+			changed = w.ix.removeDir(move.path) || changed
+		} else {
+			changed = w.ix.remove(move.path) || changed
+		}
+	}
+
+	// Edited ignore rules can hide or reveal files anywhere below them, which
+	// the events above say nothing about, so list everything again.
+	if rulesChanged {
+		w.ix.logger.Info("Ignore rules changed, rebuilding index")
+		w.rebuild()
+		changed = true
+	}
+	return changed
+}
+
+// rebuild rebuilds the index from scratch and watches any directories it gained.
+func (w *watcher) rebuild() {
+	if err := w.ix.Build(); err != nil {
+		w.ix.logger.Error("Rebuilding index failed", "err", err)
+	}
+	w.mu.Lock()
+	known := make(map[string]bool, len(w.wds))
+	for dir := range w.wds {
+		known[dir] = true
+	}
+	w.mu.Unlock()
+	for _, dir := range w.ix.dirs() {
+		if !known[dir] {
+			w.add(dir)
+		}
+	}
+}
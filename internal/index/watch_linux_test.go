@@ -0,0 +1,121 @@
+//go:build linux
+
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"prompty/internal/logging"
+	"testing"
+	"time"
+)
+
+// waitFor waits until cond holds, re-checking whenever the index reports a change.
+func waitFor(t *testing.T, ix *Index, what string, cond func() bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for !cond() {
+		select {
+		case <-ix.Changes():
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s; index has %q", what, ix.Paths())
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "pkg/a.go", "package pkg\n")
+	writeFile(t, root, "pkg/sub/b.go", "package sub\n")
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ix := New(root, logging.Discard())
+	if err := ix.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if err := ix.Watch(); err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	defer ix.Close()
+
+	has := func(p string) bool { _, ok := ix.Lookup(p); return ok }
+	abs := func(p string) string { return filepath.Join(root, filepath.FromSlash(p)) }
+
+	writeFile(t, root, "pkg/new.go", "package pkg\n")
+	waitFor(t, ix, "a created file", func() bool { return has("pkg/new.go") })
+
+	writeFile(t, root, "pkg/new.go", "package pkg\n\nvar x int\n")
+	waitFor(t, ix, "a rewritten file", func() bool {
+		entry, _ := ix.Lookup("pkg/new.go")
+		return entry.Size == int64(len("package pkg\n\nvar x int\n"))
+	})
+
+	if err := os.Rename(abs("main.go"), abs("cmd.go")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, ix, "a renamed file", func() bool { return has("cmd.go") && !has("main.go") })
+
+	// Renaming a directory moves everything below it, and the new name stays watched.
+	if err := os.Rename(abs("pkg"), abs("lib")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, ix, "a renamed directory", func() bool {
+		return has("lib/a.go") && has("lib/sub/b.go") && !has("pkg/a.go") && !has("pkg/sub/b.go")
+	})
+	writeFile(t, root, "lib/sub/c.go", "package sub\n")
+	waitFor(t, ix, "a file in a renamed directory", func() bool { return has("lib/sub/c.go") })
+
+	// A directory that was empty at Build is watched like any other.
+	writeFile(t, root, "empty/first.go", "package empty\n")
+	waitFor(t, ix, "a file in a directory that was empty", func() bool { return has("empty/first.go") })
+
+	// A directory created after Build is indexed and watched too.
+	writeFile(t, root, "docs/guide.md", "# guide\n")
+	waitFor(t, ix, "a file in a new directory", func() bool { return has("docs/guide.md") })
+	writeFile(t, root, "docs/faq.md", "# faq\n")
+	waitFor(t, ix, "a second file in a new directory", func() bool { return has("docs/faq.md") })
+
+	if err := os.Remove(abs("cmd.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(abs("lib")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, ix, "removed files", func() bool {
+		return !has("cmd.go") && !has("lib/a.go") && !has("lib/sub/c.go")
+	})
+}
+
+func TestWatchIgnoreRules(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	writeFile(t, root, ".promptyignore", "*.log\n")
+	writeFile(t, root, "main.go", "package main\n")
+
+	ix := New(root, logging.Discard())
+	if err := ix.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if err := ix.Watch(); err != nil {
+		t.Fatalf("Watch() failed: %v", err)
+	}
+	defer ix.Close()
+
+	has := func(p string) bool { _, ok := ix.Lookup(p); return ok }
+
+	// New files matching a rule stay out of the index.
+	writeFile(t, root, "debug.log", "...\n")
+	writeFile(t, root, "marker.go", "package main\n")
+	waitFor(t, ix, "a created file", func() bool { return has("marker.go") })
+	if has("debug.log") {
+		t.Error("an ignored file was indexed")
+	}
+
+	// Editing the rules re-applies them to files already on disk.
+	writeFile(t, root, ".promptyignore", "marker.go\n")
+	waitFor(t, ix, "the new rules", func() bool { return has("debug.log") && !has("marker.go") })
+}
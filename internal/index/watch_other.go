@@ -0,0 +1,17 @@
+//go:build !linux
+
+package index
+
+// watcher is a placeholder on platforms without a filesystem watcher;
+// the index is still built once at startup but won't follow changes.
+type watcher struct{}
+
+// newWatcher always fails with ErrWatchUnsupported.
+func newWatcher(ix *Index) (*watcher, error) {
+	return nil, ErrWatchUnsupported
+}
+
+// close is a no-op.
+func (w *watcher) close() error {
+	return nil
+}
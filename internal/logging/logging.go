@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+)
+
+// Attribute keys used across prompty's log records. Keeping them in one place
+// lets the redacting handler know which ones may hold what the user typed.
+// File contents and prompt text are never logged, so they need no key.
+const (
+	KeyModel   = "model"    // Which UI model logged the record (search, browse, compose, app)
+	KeyMsgType = "msg_type" // Go type of the Bubble Tea message being handled
+	KeyPath    = "path"     // A file path, relative to the project root
+	KeyQuery   = "query"    // Text typed into the search input (sensitive)
+	KeyError   = "err"      // An error value
+)
+
+// sensitiveKeys are the attributes replaced when redaction is on.
+var sensitiveKeys = map[string]bool{
+	KeyQuery: true,
+}
+
+// SavedQuery returns the attribute for a query saved under a name: the name
+// and the query text, grouped under KeyQuery so redaction covers both.
+func SavedQuery(name, text string) slog.Attr {
+	return slog.Group(KeyQuery, slog.String("name", name), slog.String("text", text))
+}
+
+// ParseLevel parses a level name as accepted by the -log-level flag:
+// debug, info, warn or error (case-insensitive).
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: use debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// New returns a logger writing text records at or above level to w. With
+// redact set, attributes that hold what the user typed (search queries and
+// the names of saved queries) are replaced by their length, so the log can be
+// attached to a bug report. File paths are kept.
+func New(w io.Writer, level slog.Level, redact bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if redact {
+		opts.ReplaceAttr = redactAttr
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Discard returns a logger that drops every record, for when logging is off.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// redactAttr replaces the value of sensitive attributes, and of every
+// attribute in a group with a sensitive key (see SavedQuery), with a short
+// description.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	inSensitiveGroup := slices.ContainsFunc(groups, func(g string) bool { return sensitiveKeys[g] })
+	if !sensitiveKeys[attr.Key] && !inSensitiveGroup {
+		return attr
+	}
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindString {
+		return slog.String(attr.Key, fmt.Sprintf("[redacted, %d bytes]", len(value.String())))
+	}
+	return slog.String(attr.Key, "[redacted]")
+}
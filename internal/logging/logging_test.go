@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	for _, redact := range []bool{false, true} {
+		var buf bytes.Buffer
+		New(&buf, slog.LevelInfo, redact).Info("Searching",
+			KeyPath, "internal/secret.go",
+			KeyQuery, "password",
+		)
+		out := buf.String()
+
+		// Paths are kept either way; only the query text is hidden.
+		if !strings.Contains(out, "path=internal/secret.go") {
+			t.Errorf("redact=%v: path missing from %q", redact, out)
+		}
+		if got := strings.Contains(out, "password"); got == redact {
+			t.Errorf("redact=%v: query text logged = %v in %q", redact, got, out)
+		}
+		if redact && !strings.Contains(out, `query="[redacted, 8 bytes]"`) {
+			t.Errorf("redact=%v: want the query length in %q", redact, out)
+		}
+	}
+}
+
+func TestRedactSavedQuery(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, slog.LevelInfo, true).Info("Saved query", SavedQuery("payroll", "ext:go salary"))
+	out := buf.String()
+	// Both the name and the text are what the user typed.
+	if strings.Contains(out, "payroll") || strings.Contains(out, "salary") {
+		t.Errorf("saved query not redacted: %q", out)
+	}
+	if !strings.Contains(out, `query.name="[redacted, 7 bytes]"`) {
+		t.Errorf("want the redacted name under the query group in %q", out)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	if err != nil || level != slog.LevelWarn {
+		t.Fatalf("ParseLevel(WARN) = %v, %v; want warn", level, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) succeeded, want an error")
+	}
+
+	var buf bytes.Buffer
+	logger := New(&buf, level, false)
+	logger.Info("dropped")
+	logger.Warn("kept")
+	if out := buf.String(); strings.Contains(out, "dropped") || !strings.Contains(out, "kept") {
+		t.Errorf("logging at warn wrote %q", out)
+	}
+}
@@ -0,0 +1,137 @@
+package order
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// minNameLen is the shortest declared name that counts as a dependency; short
+// names like "id" or "Do" are too likely to appear by chance.
+const minNameLen = 3
+
+// declPattern finds declarations in languages other than Go: classes,
+// structs, interfaces, traits, enums, types and functions, after any export,
+// visibility or async keywords.
+var declPattern = regexp.MustCompile(`(?m)^[ \t]*(?:(?:export|default|pub(?:\([a-z]+\))?|public|private|protected|internal|abstract|final|static|async|data|sealed|open)[ \t]+)*(?:class|struct|interface|trait|enum|type|typedef|record|object|def|func|fun|fn|function)[ \t]+\*?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// wordPattern finds the identifiers a file uses.
+var wordPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// ignoredNames are declared names too common to say anything about dependencies.
+var ignoredNames = map[string]bool{
+	"main": true, "init": true, "new": true, "test": true, "setup": true,
+	"run": true, "get": true, "set": true, "String": true, "Error": true,
+}
+
+// sortByDependencies orders files so that a file comes before the files that
+// use the names it declares, e.g. the file defining a type before its callers.
+// Files that don't depend on each other keep their current order.
+func sortByDependencies(files []File) []int {
+	// Who declares each name.
+	declaredBy := make(map[string][]int)
+	declared := make([]map[string]bool, len(files))
+	for i, f := range files {
+		declared[i] = make(map[string]bool)
+		for _, name := range declarations(f) {
+			if len(name) < minNameLen || ignoredNames[name] || declared[i][name] {
+				continue
+			}
+			declared[i][name] = true
+			declaredBy[name] = append(declaredBy[name], i)
+		}
+	}
+
+	// deps[i] holds the files whose names file i uses.
+	deps := make([]map[int]bool, len(files))
+	for i, f := range files {
+		deps[i] = make(map[int]bool)
+		for _, word := range wordPattern.FindAllString(f.Content, -1) {
+			if declared[i][word] {
+				continue // Its own name, even if another file declares it too
+			}
+			for _, j := range declaredBy[word] {
+				deps[i][j] = true
+			}
+		}
+	}
+
+	// Place, again and again, the earliest file whose dependencies are all
+	// placed. In a cycle there is none; then the file waiting for the fewest
+	// others goes first.
+	placed := make([]bool, len(files))
+	order := make([]int, 0, len(files))
+	for len(order) < len(files) {
+		next, fewest := -1, len(files)+1
+		for i := range files {
+			if placed[i] {
+				continue
+			}
+			waiting := 0
+			for j := range deps[i] {
+				if !placed[j] {
+					waiting++
+				}
+			}
+			if waiting < fewest {
+				next, fewest = i, waiting
+			}
+			if waiting == 0 {
+				break
+			}
+		}
+		placed[next] = true
+		order = append(order, next)
+	}
+	return order
+}
+
+// declarations returns the top-level names a file declares. Go files are
+// parsed; other files are scanned for declaration keywords.
+func declarations(f File) []string {
+	if strings.HasSuffix(f.Path, ".go") {
+		if names, ok := goDeclarations(f); ok {
+			return names
+		}
+	}
+	var names []string
+	for _, m := range declPattern.FindAllStringSubmatch(f.Content, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// goDeclarations returns the functions, types, constants and variables a Go
+// file declares at package level. Methods are left out: their names say
+// little about which file a caller needs first, their receiver type does.
+func goDeclarations(f File) ([]string, bool) {
+	file, err := parser.ParseFile(token.NewFileSet(), f.Path, f.Content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.Name != "_" {
+							names = append(names, name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return names, true
+}
@@ -0,0 +1,130 @@
+// Package order arranges the tagged files of a prompt. Besides keeping the
+// order the user gave them, it can sort them by path, group them by
+// directory, put configuration files first, or put files before the files
+// that use what they define, so a model reads the core types before their
+// callers.
+package order
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// Strategy selects how the tagged files are ordered in the prompt.
+type Strategy int
+
+const (
+	Manual       Strategy = iota // 0: The order the files were tagged in, as moved by hand
+	ByPath                       // 1: Alphabetically by path
+	ByDirectory                  // 2: Directory by directory, a directory's files before its subdirectories
+	ConfigsFirst                 // 3: Manifests and configuration files first, the rest as they were
+	Dependencies                 // 4: Files before the files that use what they define
+)
+
+// String returns the short, lower-case name of the strategy.
+func (s Strategy) String() string {
+	switch s {
+	case ByPath:
+		return "path"
+	case ByDirectory:
+		return "directory"
+	case ConfigsFirst:
+		return "configs first"
+	case Dependencies:
+		return "dependencies"
+	default:
+		return "manual"
+	}
+}
+
+// Next returns the strategy that follows s when cycling through them.
+func (s Strategy) Next() Strategy {
+	return (s + 1) % (Dependencies + 1)
+}
+
+// File is what a strategy knows about a tagged file.
+type File struct {
+	Path    string // Slash-separated path relative to the project root
+	Content string // The file's text; only Dependencies looks at it
+}
+
+// Sort returns the order in which files should appear under strategy s, as
+// indexes into files. Files the strategy considers equal keep their current
+// order, and Manual keeps the current order altogether.
+func Sort(s Strategy, files []File) []int {
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	switch s {
+	case ByPath:
+		slices.SortStableFunc(order, func(a, b int) int {
+			return strings.Compare(files[a].Path, files[b].Path)
+		})
+	case ByDirectory:
+		slices.SortStableFunc(order, func(a, b int) int {
+			return compareByDirectory(files[a].Path, files[b].Path)
+		})
+	case ConfigsFirst:
+		slices.SortStableFunc(order, func(a, b int) int {
+			ca, cb := IsConfig(files[a].Path), IsConfig(files[b].Path)
+			switch {
+			case ca && !cb:
+				return -1
+			case cb && !ca:
+				return 1
+			}
+			return 0
+		})
+	case Dependencies:
+		order = sortByDependencies(files)
+	}
+	return order
+}
+
+// compareByDirectory orders paths by their directory first, comparing it
+// segment by segment so the files directly in a directory come right before
+// those in its subdirectories, and then by name.
+func compareByDirectory(a, b string) int {
+	if c := slices.Compare(dirSegments(a), dirSegments(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(path.Base(a), path.Base(b))
+}
+
+// dirSegments splits the directory of p into its names; it is empty for a
+// file in the root directory.
+func dirSegments(p string) []string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// configNames are the base names (in lower case) of manifests, build files and
+// other configuration files that have no telling extension.
+var configNames = map[string]bool{
+	"go.mod": true, "go.work": true, "package.json": true, "cargo.toml": true,
+	"pyproject.toml": true, "setup.py": true, "setup.cfg": true, "requirements.txt": true,
+	"gemfile": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+	"makefile": true, "dockerfile": true, "cmakelists.txt": true, "justfile": true,
+	".editorconfig": true, ".gitignore": true, ".promptyignore": true,
+}
+
+// configExts are the extensions of configuration formats.
+var configExts = map[string]bool{
+	".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".cfg": true,
+	".conf": true, ".json": true, ".properties": true, ".env": true,
+}
+
+// IsConfig reports whether the file at p looks like a manifest or
+// configuration file, e.g. go.mod, package.json, Dockerfile or config.yaml.
+func IsConfig(p string) bool {
+	base := strings.ToLower(path.Base(p))
+	if configNames[base] || strings.HasPrefix(base, "dockerfile") || strings.HasPrefix(base, ".env") {
+		return true
+	}
+	return configExts[path.Ext(base)]
+}
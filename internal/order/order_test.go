@@ -0,0 +1,234 @@
+package order
+
+import (
+	"slices"
+	"testing"
+)
+
+// paths returns the paths of files in the order given by indexes.
+func paths(files []File, indexes []int) []string {
+	out := make([]string, len(indexes))
+	for i, j := range indexes {
+		out[i] = files[j].Path
+	}
+	return out
+}
+
+// filesAt returns files with the given paths and no content.
+func filesAt(ps ...string) []File {
+	files := make([]File, len(ps))
+	for i, p := range ps {
+		files[i] = File{Path: p}
+	}
+	return files
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		files    []File
+		want     []string
+	}{
+		{
+			name:     "manual keeps the current order",
+			strategy: Manual,
+			files:    filesAt("b.go", "a.go", "c/d.go"),
+			want:     []string{"b.go", "a.go", "c/d.go"},
+		},
+		{
+			name:     "by path",
+			strategy: ByPath,
+			files:    filesAt("b.go", "a/z.go", "a.go", "a-c/y.go"),
+			want:     []string{"a-c/y.go", "a.go", "a/z.go", "b.go"},
+		},
+		{
+			name:     "by directory puts a directory's files before its subdirectories",
+			strategy: ByDirectory,
+			files:    filesAt("b/x.go", "a/b/c.go", "a-c/y.go", "a/z.go", "a.go"),
+			want:     []string{"a.go", "a/z.go", "a/b/c.go", "a-c/y.go", "b/x.go"},
+		},
+		{
+			name:     "configs first keeps the rest in order",
+			strategy: ConfigsFirst,
+			files:    filesAt("main.go", "go.mod", "README.md", "deploy/config.yaml", "Dockerfile.dev", "util.go"),
+			want:     []string{"go.mod", "deploy/config.yaml", "Dockerfile.dev", "main.go", "README.md", "util.go"},
+		},
+		{
+			name:     "empty",
+			strategy: Dependencies,
+			files:    nil,
+			want:     []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paths(tt.files, Sort(tt.strategy, tt.files))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Sort(%s) = %q, want %q", tt.strategy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []File
+		want  []string
+	}{
+		{
+			name: "a declaration comes before its use",
+			files: []File{
+				{Path: "handler.go", Content: "package p\n\nfunc Handle() { _ = Widget{} }\n"},
+				{Path: "widget.go", Content: "package p\n\ntype Widget struct{}\n"},
+			},
+			want: []string{"widget.go", "handler.go"},
+		},
+		{
+			name: "chains are followed",
+			files: []File{
+				{Path: "a.go", Content: "package p\n\nfunc Alpha() { Beta() }\n"},
+				{Path: "b.go", Content: "package p\n\nfunc Beta() { Gamma() }\n"},
+				{Path: "c.go", Content: "package p\n\nfunc Gamma() {}\n"},
+			},
+			want: []string{"c.go", "b.go", "a.go"},
+		},
+		{
+			name: "independent files keep their order",
+			files: []File{
+				{Path: "z.go", Content: "package p\n\nfunc Zed() {}\n"},
+				{Path: "y.go", Content: "package p\n\nfunc Why() {}\n"},
+				{Path: "x.go", Content: "package p\n\nfunc Ex() {}\n"},
+			},
+			want: []string{"z.go", "y.go", "x.go"},
+		},
+		{
+			name: "a cycle is broken at the file waiting for the fewest others",
+			files: []File{
+				{Path: "x.go", Content: "package p\n\nfunc Xxx() { Yyy() }\n"},
+				{Path: "y.go", Content: "package p\n\nfunc Yyy() { Xxx() }\n"},
+				{Path: "z.go", Content: "package p\n\nfunc Zzz() {}\n"},
+			},
+			want: []string{"z.go", "x.go", "y.go"},
+		},
+		{
+			name: "names shorter than minNameLen don't count",
+			files: []File{
+				{Path: "a.go", Content: "package p\n\nfunc Run() { Do() }\n"},
+				{Path: "b.go", Content: "package p\n\nfunc Do() {}\n"},
+			},
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "ignored names don't count",
+			files: []File{
+				{Path: "a.go", Content: "package p\n\nfunc Use() { _ = String() }\n"},
+				{Path: "b.go", Content: "package p\n\nfunc String() string { return \"\" }\n"},
+			},
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "methods don't count, their receiver type does",
+			files: []File{
+				{Path: "a.go", Content: "package p\n\nfunc Use(s Server) { s.Serve() }\n"},
+				{Path: "b.go", Content: "package p\n\nfunc (Other) Serve() {}\n"},
+				{Path: "c.go", Content: "package p\n\ntype Server struct{}\n"},
+			},
+			want: []string{"b.go", "c.go", "a.go"},
+		},
+		{
+			name: "a file declaring the name itself doesn't depend on others declaring it",
+			files: []File{
+				{Path: "a.go", Content: "package p\n\ntype Config struct{}\n"},
+				{Path: "b.go", Content: "package q\n\ntype Config struct{}\n"},
+			},
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "a Go file that doesn't parse is scanned like other languages",
+			files: []File{
+				{Path: "main.go", Content: "package main\n\nfunc main() { NewWidget() }\n"},
+				{Path: "widget.go", Content: "package main\n\nfunc NewWidget( {\n"},
+			},
+			want: []string{"widget.go", "main.go"},
+		},
+		{
+			name: "other languages",
+			files: []File{
+				{Path: "app.py", Content: "from parser import Parser\n\nParser().run()\n"},
+				{Path: "parser.py", Content: "class Parser:\n    def run(self):\n        pass\n"},
+				{Path: "ui.ts", Content: "export async function render() {}\n"},
+			},
+			want: []string{"parser.py", "app.py", "ui.ts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paths(tt.files, sortByDependencies(tt.files))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortByDependencies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareByDirectory(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"a.go", "b.go", -1},
+		{"z.go", "a/a.go", -1},       // Root files come first
+		{"a/z.go", "a/b/a.go", -1},   // A directory's files before its subdirectories
+		{"a/b/c.go", "a-c/y.go", -1}, // Directories compared segment by segment
+		{"b/x.go", "a/x.go", 1},
+		{"a/x.go", "a/x.go", 0},
+	}
+	for _, tt := range tests {
+		if got := compareByDirectory(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareByDirectory(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsConfig(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"go.mod", true},
+		{"web/package.json", true},
+		{"Makefile", true},
+		{"Dockerfile", true},
+		{"build/Dockerfile.prod", true},
+		{".env.local", true},
+		{"deploy/values.YAML", true},
+		{"config/app.toml", true},
+		{"main.go", false},
+		{"README.md", false},
+		{"docs/makefile.md", false},
+		{"internal/config/config.go", false},
+	}
+	for _, tt := range tests {
+		if got := IsConfig(tt.path); got != tt.want {
+			t.Errorf("IsConfig(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStrategyNext(t *testing.T) {
+	s := Manual
+	var seen []string
+	for range Dependencies + 1 {
+		seen = append(seen, s.String())
+		s = s.Next()
+	}
+	if s != Manual {
+		t.Errorf("cycling through every strategy ended at %s, want manual", s)
+	}
+	want := []string{"manual", "path", "directory", "configs first", "dependencies"}
+	if !slices.Equal(seen, want) {
+		t.Errorf("strategies = %q, want %q", seen, want)
+	}
+}
@@ -0,0 +1,407 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FuzzyMatch represents a single candidate accepted by the fuzzy matcher.
+// It includes the matched string, its score and which characters matched.
+type FuzzyMatch struct {
+	Str            string // The candidate string that matched (usually a file path)
+	Index          int    // Position of the candidate in the slice passed to FuzzyFind
+	Score          int    // Relevance score; higher is better
+	MatchedIndexes []int  // Rune offsets into Str of the characters hit by the pattern, ascending
+}
+
+// Scoring weights, loosely modelled on fzf's algorithm so results feel familiar.
+const (
+	scoreMatch        = 16 // Every matched character
+	scoreGapStart     = -3 // Starting a gap between two matched characters
+	scoreGapExtension = -1 // Each further character inside a gap
+	bonusBoundary     = 8  // Match right after a separator such as '/', '_', '-' or '.'
+	bonusCamel        = 7  // Match on an upper-case letter following a lower-case one
+	bonusConsecutive  = 5  // Match directly after the previous matched character
+	bonusFirstChar    = 2  // Multiplier applied to the bonus of the pattern's first character
+	bonusBasename     = 24 // The whole term matched inside the last path segment
+)
+
+// FuzzyFind matches pattern against every item and returns the accepted items
+// sorted by descending score. Ties are broken by shorter, then lexically smaller strings.
+//
+// The pattern is split on whitespace and every term has to match (in any order),
+// like fzf's extended search. Matching is case-insensitive unless the term
+// contains an upper-case letter (smart case). An empty pattern accepts every
+// item with a score of 0, preserving the input order.
+func FuzzyFind(pattern string, items []string) []FuzzyMatch {
+	matches, _ := FuzzyFindContext(context.Background(), pattern, items)
+	return matches
+}
+
+// FuzzyFindContext is like FuzzyFind but stops early and returns ctx.Err()
+// once ctx is cancelled, e.g. because a newer query superseded this one.
+func FuzzyFindContext(ctx context.Context, pattern string, items []string) ([]FuzzyMatch, error) {
+	terms := compileTerms(pattern)
+
+	// Large lists are split into chunks that are matched in parallel.
+	workers := runtime.GOMAXPROCS(0)
+	if len(items) < parallelThreshold || workers < 2 {
+		workers = 1
+	}
+	chunkSize := (len(items) + workers - 1) / workers
+	chunks := make([][]FuzzyMatch, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		lo := min(w*chunkSize, len(items))
+		hi := min(lo+chunkSize, len(items))
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			chunks[w] = fuzzyFindRange(ctx, terms, items, lo, hi)
+		}(w, lo, hi)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matches := slices.Concat(chunks...)
+	if len(terms) > 0 {
+		slices.SortFunc(matches, compareFuzzyMatch)
+	}
+	return matches, nil
+}
+
+// BoostMatches adds boost(match.Str) to the score of every match and re-sorts
+// them by score. The sort is stable, so matches with equal scores keep the order
+// they had before, including the input order of an empty pattern.
+func BoostMatches(matches []FuzzyMatch, boost func(str string) int) {
+	boosted := false
+	for i := range matches {
+		if b := boost(matches[i].Str); b != 0 {
+			matches[i].Score += b
+			boosted = true
+		}
+	}
+	if boosted {
+		slices.SortStableFunc(matches, func(a, b FuzzyMatch) int {
+			return cmp.Compare(b.Score, a.Score)
+		})
+	}
+}
+
+// parallelThreshold is the list size below which FuzzyFind doesn't bother with goroutines.
+const parallelThreshold = 10000
+
+// cancelCheckInterval is how many items are matched between checks for cancellation.
+const cancelCheckInterval = 4096
+
+// fuzzyFindRange matches terms against items[lo:hi], in input order.
+// It gives up early (returning partial results) when ctx is cancelled.
+func fuzzyFindRange(ctx context.Context, terms []fuzzyTerm, items []string, lo, hi int) []FuzzyMatch {
+	var matches []FuzzyMatch
+	var buf []rune // Reused across items to avoid a rune slice allocation per candidate
+	for i := lo; i < hi; i++ {
+		if (i-lo)%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return matches
+		}
+		var score int
+		var positions []int
+		var ok bool
+		score, positions, buf, ok = fuzzyMatchTerms(terms, items[i], buf)
+		if !ok {
+			continue
+		}
+		matches = append(matches, FuzzyMatch{Str: items[i], Index: i, Score: score, MatchedIndexes: positions})
+	}
+	return matches
+}
+
+// FuzzyScore matches pattern against a single string. It reports whether the
+// string was accepted, its score and the rune offsets of the matched characters.
+func FuzzyScore(pattern string, str string) (score int, positions []int, ok bool) {
+	score, positions, _, ok = fuzzyMatchTerms(compileTerms(pattern), str, nil)
+	return score, positions, ok
+}
+
+// fuzzyTerm is one whitespace-separated word of a pattern, prepared for matching.
+type fuzzyTerm struct {
+	runes         []rune // The term itself
+	caseSensitive bool   // Smart case: set when the term contains an upper-case letter
+}
+
+// compileTerms splits pattern into terms once, so matching doesn't redo it per item.
+func compileTerms(pattern string) []fuzzyTerm {
+	fields := strings.Fields(pattern)
+	terms := make([]fuzzyTerm, 0, len(fields))
+	for _, field := range fields {
+		runes := []rune(field)
+		terms = append(terms, fuzzyTerm{runes: runes, caseSensitive: hasUpper(runes)})
+	}
+	return terms
+}
+
+// compareFuzzyMatch orders matches by score, then length, then lexically, then input order.
+func compareFuzzyMatch(a, b FuzzyMatch) int {
+	if a.Score != b.Score {
+		return cmp.Compare(b.Score, a.Score)
+	}
+	if len(a.Str) != len(b.Str) {
+		return cmp.Compare(len(a.Str), len(b.Str))
+	}
+	if c := strings.Compare(a.Str, b.Str); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Index, b.Index)
+}
+
+// fuzzyMatchTerms matches every term against str and merges their scores and positions.
+// buf is scratch space for decoding str and is returned (possibly grown) for reuse.
+func fuzzyMatchTerms(terms []fuzzyTerm, str string, buf []rune) (int, []int, []rune, bool) {
+	if len(terms) == 0 {
+		return 0, nil, buf, true
+	}
+
+	// Cheap rejection first: most candidates don't contain the terms at all,
+	// and this avoids decoding them to runes.
+	for _, term := range terms {
+		if !containsSubsequence(str, term) {
+			return 0, nil, buf, false
+		}
+	}
+
+	text := buf[:0]
+	for _, r := range str {
+		text = append(text, r)
+	}
+	total := 0
+	var positions []int
+	for _, term := range terms {
+		score, termPositions, ok := fuzzyMatchTerm(term, text)
+		if !ok {
+			return 0, nil, text, false
+		}
+		total += score
+		positions = append(positions, termPositions...)
+	}
+
+	if len(terms) > 1 {
+		positions = sortUniqueInts(positions)
+	}
+	return total, positions, text, true
+}
+
+// fuzzyMatchTerm matches a single term against text.
+//
+// It first looks for the term inside the last path segment, since that's what
+// people usually type, and otherwise in the whole string. Within the chosen
+// region it finds the shortest window that contains the term (a forward scan
+// for the earliest end, then a backward scan for the latest start) and scores
+// the characters matched inside that window.
+func fuzzyMatchTerm(term fuzzyTerm, text []rune) (int, []int, bool) {
+	pattern, caseSensitive := term.runes, term.caseSensitive
+
+	base := lastSegmentStart(text)
+	if base > 0 {
+		if start, end, ok := findWindow(pattern, text, base, caseSensitive); ok {
+			score, positions := scoreWindow(pattern, text, start, end, caseSensitive)
+			return score + bonusBasename, positions, true
+		}
+	}
+
+	start, end, ok := findWindow(pattern, text, 0, caseSensitive)
+	if !ok {
+		return 0, nil, false
+	}
+	score, positions := scoreWindow(pattern, text, start, end, caseSensitive)
+	if base == 0 {
+		// The string has no directory part, so every match is a basename match.
+		score += bonusBasename
+	}
+	return score, positions, true
+}
+
+// containsSubsequence reports whether the term's runes appear in str in order.
+func containsSubsequence(str string, term fuzzyTerm) bool {
+	pattern := term.runes
+	if len(pattern) == 0 {
+		return true
+	}
+	pi := 0
+	for _, r := range str {
+		if equalRune(r, pattern[pi], term.caseSensitive) {
+			pi++
+			if pi == len(pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findWindow returns the shortest [start, end) window of text, beginning at or
+// after from, that contains pattern as a subsequence.
+func findWindow(pattern []rune, text []rune, from int, caseSensitive bool) (int, int, bool) {
+	if len(pattern) == 0 {
+		return from, from, true
+	}
+
+	// Forward scan: the earliest position at which the whole pattern has been seen.
+	pi := 0
+	end := -1
+	for ti := from; ti < len(text); ti++ {
+		if equalRune(text[ti], pattern[pi], caseSensitive) {
+			pi++
+			if pi == len(pattern) {
+				end = ti + 1
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, 0, false
+	}
+
+	// Backward scan: the latest start that still contains the pattern before end.
+	pi = len(pattern) - 1
+	start := end - 1
+	for ti := end - 1; ti >= from; ti-- {
+		if equalRune(text[ti], pattern[pi], caseSensitive) {
+			pi--
+			if pi < 0 {
+				start = ti
+				break
+			}
+		}
+	}
+	return start, end, true
+}
+
+// scoreWindow greedily matches pattern inside text[start:end] and scores the result.
+func scoreWindow(pattern []rune, text []rune, start, end int, caseSensitive bool) (int, []int) {
+	positions := make([]int, 0, len(pattern))
+	score := 0
+	pi := 0
+	prevMatch := -1
+	inGap := false
+
+	for ti := start; ti < end && pi < len(pattern); ti++ {
+		if !equalRune(text[ti], pattern[pi], caseSensitive) {
+			if prevMatch >= 0 {
+				if inGap {
+					score += scoreGapExtension
+				} else {
+					score += scoreGapStart
+					inGap = true
+				}
+			}
+			continue
+		}
+
+		bonus := charBonus(text, ti)
+		if prevMatch >= 0 && prevMatch == ti-1 {
+			// Consecutive matches earn at least bonusConsecutive, so a contiguous
+			// run like "main" beats the same letters scattered across the path.
+			bonus = max(bonus, bonusConsecutive)
+		}
+		if pi == 0 {
+			bonus *= bonusFirstChar
+		}
+
+		score += scoreMatch + bonus
+		positions = append(positions, ti)
+		prevMatch = ti
+		inGap = false
+		pi++
+	}
+	return score, positions
+}
+
+// charBonus returns the positional bonus for matching text[i].
+func charBonus(text []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev, cur := text[i-1], text[i]
+	switch {
+	case isSeparator(prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	case !unicode.IsDigit(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+// isSeparator reports whether r separates words in a path.
+func isSeparator(r rune) bool {
+	switch r {
+	case '/', '\\', '_', '-', '.', ' ', ':':
+		return true
+	}
+	return false
+}
+
+// lastSegmentStart returns the rune offset just after the last '/' in text, or 0.
+func lastSegmentStart(text []rune) int {
+	for i := len(text) - 1; i >= 0; i-- {
+		if text[i] == '/' {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// equalRune compares two runes, folding case unless caseSensitive is set.
+func equalRune(a, b rune, caseSensitive bool) bool {
+	if a == b {
+		return true
+	}
+	if caseSensitive {
+		return false
+	}
+	if a < utf8.RuneSelf && b < utf8.RuneSelf {
+		// Paths are overwhelmingly ASCII, so avoid the unicode tables when we can.
+		return lowerASCII(a) == lowerASCII(b)
+	}
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// lowerASCII lower-cases an ASCII letter and returns any other rune unchanged.
+func lowerASCII(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r + ('a' - 'A')
+	}
+	return r
+}
+
+// hasUpper reports whether any rune in s is upper-case.
+func hasUpper(s []rune) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortUniqueInts sorts ints in place and removes duplicates.
+func sortUniqueInts(ints []int) []int {
+	sort.Ints(ints)
+	out := ints[:0]
+	for i, v := range ints {
+		if i == 0 || v != ints[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// matchedStrings returns the strings of matches, in order.
+func matchedStrings(matches []FuzzyMatch) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.Str
+	}
+	return out
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, str  string
+		ok            bool
+		wantPositions []int // Checked when ok
+	}{
+		{"", "anything", true, nil},
+		{"mdl", "model.go", true, []int{0, 2, 4}},
+		{"MODEL", "model.go", false, nil}, // Smart case: an upper-case letter makes the term case-sensitive
+		{"model", "MODEL.go", true, []int{0, 1, 2, 3, 4}},
+		{"lm", "model.go", false, nil}, // Order matters
+		{"search go", "internal/search/fuzzy.go", true, []int{9, 10, 11, 12, 13, 14, 22, 23}},
+		{"search rust", "internal/search/fuzzy.go", false, nil}, // Every term has to match
+		{"ü", "internal/über.go", true, []int{9}},               // Offsets are in runes, not bytes
+	}
+	for _, tt := range tests {
+		_, positions, ok := FuzzyScore(tt.pattern, tt.str)
+		if ok != tt.ok {
+			t.Errorf("FuzzyScore(%q, %q) ok = %v, want %v", tt.pattern, tt.str, ok, tt.ok)
+			continue
+		}
+		if ok && !slices.Equal(positions, tt.wantPositions) {
+			t.Errorf("FuzzyScore(%q, %q) positions = %v, want %v", tt.pattern, tt.str, positions, tt.wantPositions)
+		}
+	}
+}
+
+func TestFuzzyFindRanking(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		items   []string
+		want    []string // Best first
+	}{
+		{
+			name:    "base name before directory",
+			pattern: "search",
+			items:   []string{"internal/search/fuzzy.go", "cmd/search.go"},
+			want:    []string{"cmd/search.go", "internal/search/fuzzy.go"},
+		},
+		{
+			name:    "consecutive before scattered",
+			pattern: "app",
+			items:   []string{"a_p_p.go", "app.go"},
+			want:    []string{"app.go", "a_p_p.go"},
+		},
+		{
+			name:    "word boundaries before the middle of a word",
+			pattern: "ui",
+			items:   []string{"build.go", "user_input.go"},
+			want:    []string{"user_input.go", "build.go"},
+		},
+		{
+			name:    "ties go to the shorter string",
+			pattern: "a",
+			items:   []string{"ab/a.go", "a.go"},
+			want:    []string{"a.go", "ab/a.go"},
+		},
+		{
+			name:    "empty pattern keeps the input order",
+			pattern: "",
+			items:   []string{"c", "a", "b"},
+			want:    []string{"c", "a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchedStrings(FuzzyFind(tt.pattern, tt.items))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FuzzyFind(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyFindParallel(t *testing.T) {
+	// Enough items to be split across workers; the result must not depend on it.
+	items := make([]string, parallelThreshold+100)
+	for i := range items {
+		items[i] = strings.Repeat("x", i%7) + "/file.txt"
+	}
+	items[42] = "pkg/target.go"
+	items[len(items)-1] = "target.go"
+
+	got := matchedStrings(FuzzyFind("target", items))
+	want := []string{"target.go", "pkg/target.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("FuzzyFind over %d items = %q, want %q", len(items), got, want)
+	}
+}
+
+func TestFuzzyFindContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FuzzyFindContext(ctx, "a", []string{"a"}); err == nil {
+		t.Error("FuzzyFindContext with a cancelled context succeeded, want an error")
+	}
+}
+
+func TestBoostMatches(t *testing.T) {
+	matches := FuzzyFind("", []string{"a.go", "b.go", "c.go"})
+	BoostMatches(matches, func(str string) int {
+		if str == "c.go" {
+			return 10
+		}
+		return 0
+	})
+	got := matchedStrings(matches)
+	want := []string{"c.go", "a.go", "b.go"} // Unboosted matches keep their order
+	if !slices.Equal(got, want) {
+		t.Errorf("BoostMatches() = %q, want %q", got, want)
+	}
+}
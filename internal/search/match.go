@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"prompty/internal/glob"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// MatchMode selects how the search text is matched against file paths and
+// symbol names.
+type MatchMode int
+
+const (
+	MatchFuzzy MatchMode = iota // 0: fzf-style fuzzy matching (see FuzzyFind)
+	MatchExact                  // 1: Every word appears as a substring; smart case like fuzzy
+	MatchRegex                  // 2: Case-sensitive regular expression, in Go's RE2 syntax
+	MatchGlob                   // 3: Shell glob over the whole path, or over the base name if it has no '/'
+)
+
+// String returns the short, lower-case name of the match mode.
+func (mode MatchMode) String() string {
+	switch mode {
+	case MatchExact:
+		return "exact"
+	case MatchRegex:
+		return "regex"
+	case MatchGlob:
+		return "glob"
+	default:
+		return "fuzzy"
+	}
+}
+
+// Next returns the match mode that follows mode when cycling through them.
+func (mode MatchMode) Next() MatchMode {
+	return (mode + 1) % (MatchGlob + 1)
+}
+
+// ParseMatchMode returns the match mode named s (see MatchMode.String),
+// falling back to fuzzy matching for names it doesn't know.
+func ParseMatchMode(s string) MatchMode {
+	for mode := MatchFuzzy; mode <= MatchGlob; mode++ {
+		if mode.String() == s {
+			return mode
+		}
+	}
+	return MatchFuzzy
+}
+
+// Matcher is a search pattern compiled for one match mode. Compile it once per
+// query with NewMatcher; Find and FindContext can then be called on any list.
+type Matcher struct {
+	mode     MatchMode
+	pattern  string
+	terms    []fuzzyTerm    // Words of an exact pattern
+	re       *regexp.Regexp // Compiled regex or glob pattern
+	baseName bool           // Match the glob against the last path segment only
+}
+
+// NewMatcher compiles pattern for mode. It fails when pattern isn't a valid
+// regular expression or glob; fuzzy and exact patterns always compile.
+func NewMatcher(mode MatchMode, pattern string) (*Matcher, error) {
+	m := &Matcher{mode: mode, pattern: strings.TrimSpace(pattern)}
+	if m.pattern == "" {
+		return m, nil // Accepts everything, whatever the mode
+	}
+	switch mode {
+	case MatchExact:
+		m.terms = compileTerms(m.pattern)
+	case MatchRegex:
+		re, err := regexp.Compile(m.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		m.re = re
+	case MatchGlob:
+		re, err := glob.Compile(m.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob: %w", err)
+		}
+		m.re = re
+		m.baseName = !strings.Contains(m.pattern, "/")
+	}
+	return m, nil
+}
+
+// Mode returns the match mode the matcher was compiled for.
+func (m *Matcher) Mode() MatchMode {
+	return m.mode
+}
+
+// Find matches every item and returns the accepted ones best first, in the
+// same form as FuzzyFind. An empty pattern accepts every item in input order.
+func (m *Matcher) Find(items []string) []FuzzyMatch {
+	matches, _ := m.FindContext(context.Background(), items)
+	return matches
+}
+
+// FindContext is like Find but stops early and returns ctx.Err() once ctx is
+// cancelled, e.g. because a newer query superseded this one.
+func (m *Matcher) FindContext(ctx context.Context, items []string) ([]FuzzyMatch, error) {
+	if m.mode == MatchFuzzy || m.pattern == "" {
+		return FuzzyFindContext(ctx, m.pattern, items)
+	}
+
+	var matches []FuzzyMatch
+	var buf []rune
+	for i, item := range items {
+		if i%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var score int
+		var positions []int
+		var ok bool
+		switch m.mode {
+		case MatchExact:
+			buf = append(buf[:0], []rune(item)...)
+			score, positions, ok = exactMatchTerms(m.terms, buf)
+		case MatchRegex:
+			score, positions, ok = regexMatch(m.re, item)
+		case MatchGlob:
+			candidate := item
+			if m.baseName {
+				candidate = path.Base(item)
+			}
+			ok = m.re.MatchString(candidate) // Globs rank by length, then name
+		}
+		if ok {
+			matches = append(matches, FuzzyMatch{Str: item, Index: i, Score: score, MatchedIndexes: positions})
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(matches, compareFuzzyMatch)
+	return matches, nil
+}
+
+// exactMatchTerms requires every term to appear in text as a substring. Each
+// term scores its best occurrence, which favours hits in the base name and
+// at the start of a word, so "api" ranks api.go above rapid.go.
+func exactMatchTerms(terms []fuzzyTerm, text []rune) (int, []int, bool) {
+	baseStart := lastSegmentStart(text)
+	total := 0
+	var positions []int
+	for _, term := range terms {
+		best, bestStart := -1, -1
+		for start := 0; start+len(term.runes) <= len(text); start++ {
+			if !hasPrefixAt(text, term.runes, start, term.caseSensitive) {
+				continue
+			}
+			score := scoreMatch*len(term.runes) + charBonus(text, start)*bonusFirstChar
+			if start >= baseStart {
+				score += bonusBasename
+			}
+			if score > best {
+				best, bestStart = score, start
+			}
+		}
+		if bestStart < 0 {
+			return 0, nil, false
+		}
+		total += best
+		for i := range term.runes {
+			positions = append(positions, bestStart+i)
+		}
+	}
+	return total, sortUniqueInts(positions), true
+}
+
+// hasPrefixAt reports whether text continues with term at offset start.
+func hasPrefixAt(text, term []rune, start int, caseSensitive bool) bool {
+	for i, r := range term {
+		if !equalRune(r, text[start+i], caseSensitive) {
+			return false
+		}
+	}
+	return true
+}
+
+// regexMatch scores the leftmost match of re in str by where it starts (at a
+// word boundary, in the base name) but not by its length, so an anchored
+// pattern like ^internal/.*\.go$ ranks shorter paths first. It also returns
+// the rune offsets the match covers.
+func regexMatch(re *regexp.Regexp, str string) (int, []int, bool) {
+	loc := re.FindStringIndex(str)
+	if loc == nil {
+		return 0, nil, false
+	}
+	text := []rune(str)
+	start := utf8.RuneCountInString(str[:loc[0]])
+	length := utf8.RuneCountInString(str[loc[0]:loc[1]])
+	score := 0
+	if start < len(text) {
+		score += charBonus(text, start) * bonusFirstChar
+	}
+	if start >= lastSegmentStart(text) {
+		score += bonusBasename
+	}
+	positions := make([]int, length)
+	for i := range positions {
+		positions[i] = start + i
+	}
+	return score, positions, true
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	items := []string{
+		"internal/api/api.go",
+		"internal/rapid/rapid.go",
+		"internal/ui/models/search.go",
+		"README.md",
+		"cmd/Main.go",
+	}
+	tests := []struct {
+		mode    MatchMode
+		pattern string
+		want    []string // Best first
+	}{
+		{MatchExact, "api", []string{"internal/api/api.go", "internal/rapid/rapid.go"}},
+		{MatchExact, "models search", []string{"internal/ui/models/search.go"}},
+		{MatchExact, "mdl", nil}, // No fuzzy gaps
+		{MatchExact, "main", []string{"cmd/Main.go"}},
+		{MatchExact, "Main", []string{"cmd/Main.go"}},
+		{MatchExact, "MAIN", nil}, // Smart case
+		{MatchRegex, `\.md$`, []string{"README.md"}},
+		{MatchRegex, `^internal/.*\.go$`, []string{"internal/api/api.go", "internal/rapid/rapid.go", "internal/ui/models/search.go"}},
+		{MatchRegex, `main`, nil}, // Case-sensitive
+		{MatchGlob, "*.md", []string{"README.md"}},
+		{MatchGlob, "api.go", []string{"internal/api/api.go"}}, // No '/': matched against the base name
+		{MatchGlob, "internal/*/*.go", []string{"internal/api/api.go", "internal/rapid/rapid.go"}},
+		{MatchGlob, "internal/**/*.go", []string{"internal/api/api.go", "internal/rapid/rapid.go", "internal/ui/models/search.go"}},
+		{MatchGlob, "", items}, // An empty pattern accepts everything, in input order
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.mode, tt.pattern)
+		if err != nil {
+			t.Errorf("NewMatcher(%s, %q) failed: %v", tt.mode, tt.pattern, err)
+			continue
+		}
+		got := matchedStrings(m.Find(items))
+		if len(got) == 0 {
+			got = nil
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s match %q = %q, want %q", tt.mode, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherPositions(t *testing.T) {
+	tests := []struct {
+		mode    MatchMode
+		pattern string
+		str     string
+		want    []int
+	}{
+		{MatchExact, "api", "rapid/api.go", []int{6, 7, 8}}, // The base name occurrence wins
+		{MatchRegex, `s.a`, "usearch", []int{1, 2, 3}},
+		{MatchGlob, "*.go", "main.go", nil}, // Globs don't highlight
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.mode, tt.pattern)
+		if err != nil {
+			t.Fatalf("NewMatcher(%s, %q) failed: %v", tt.mode, tt.pattern, err)
+		}
+		matches := m.Find([]string{tt.str})
+		if len(matches) != 1 {
+			t.Errorf("%s match %q against %q: got %d matches, want 1", tt.mode, tt.pattern, tt.str, len(matches))
+			continue
+		}
+		if got := matches[0].MatchedIndexes; !slices.Equal(got, tt.want) {
+			t.Errorf("%s match %q against %q positions = %v, want %v", tt.mode, tt.pattern, tt.str, got, tt.want)
+		}
+	}
+}
+
+func TestNewMatcherErrors(t *testing.T) {
+	for _, tt := range []struct {
+		mode    MatchMode
+		pattern string
+	}{
+		{MatchRegex, "("},
+		{MatchGlob, "[a-"},
+	} {
+		if _, err := NewMatcher(tt.mode, tt.pattern); err == nil {
+			t.Errorf("NewMatcher(%s, %q) succeeded, want an error", tt.mode, tt.pattern)
+		}
+	}
+	// Fuzzy and exact patterns always compile.
+	for _, mode := range []MatchMode{MatchFuzzy, MatchExact} {
+		if _, err := NewMatcher(mode, "(["); err != nil {
+			t.Errorf("NewMatcher(%s, %q) failed: %v", mode, "([", err)
+		}
+	}
+}
+
+func TestParseMatchMode(t *testing.T) {
+	for mode := MatchFuzzy; mode <= MatchGlob; mode++ {
+		if got := ParseMatchMode(mode.String()); got != mode {
+			t.Errorf("ParseMatchMode(%q) = %s, want %s", mode.String(), got, mode)
+		}
+	}
+	if got := ParseMatchMode("bogus"); got != MatchFuzzy {
+		t.Errorf("ParseMatchMode(%q) = %s, want fuzzy", "bogus", got)
+	}
+	if got := MatchGlob.Next(); got != MatchFuzzy {
+		t.Errorf("MatchGlob.Next() = %s, want fuzzy", got)
+	}
+}
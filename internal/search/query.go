@@ -0,0 +1,276 @@
+package search
+
+import (
+	"fmt"
+	"path"
+	"prompty/internal/content"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TestFilter selects whether test files are included in the results.
+type TestFilter int
+
+const (
+	TestsIncluded TestFilter = iota // 0: No filtering (default)
+	TestsExcluded                   // 1: test:no, drop test files
+	TestsOnly                       // 2: test:yes, keep only test files
+)
+
+// Query is a parsed search input: the free text to match, plus the filters
+// given as key:value tokens. Filters of the same kind are ORed together
+// (ext:go ext:mod matches either), while different kinds must all hold.
+type Query struct {
+	Text          string        // Whatever is left after removing filter tokens, joined by single spaces
+	Exts          []string      // ext:go, extensions (without the dot) a file must have
+	ExcludeExts   []string      // -ext:md, extensions a file must not have
+	Paths         []string      // path:internal/, substrings the path must contain
+	ExcludePaths  []string      // -path:vendor, substrings the path must not contain
+	MinSize       int64         // size:>20k, smallest size in bytes; 0 for no limit
+	MaxSize       int64         // size:<20k, largest size in bytes; -1 for no limit
+	ChangedWithin time.Duration // changed:7d, modified no longer ago than this; 0 for no limit
+	ChangedBefore time.Duration // changed:>7d, modified at least this long ago; 0 for no limit
+	Tests         TestFilter    // test:yes / test:no
+}
+
+// ParseQuery splits input into filter tokens and free text. Recognized tokens are
+//
+//	ext:go          file extension (ext:.go works too)
+//	path:internal/  path contains the substring
+//	size:<20k       size below (or >, <=, >= ) a number with an optional b/k/m/g suffix
+//	changed:7d      modified within a duration (m, h, d or w); changed:>7d for older
+//	test:no         exclude test files; test:yes keeps only test files
+//
+// Any of them can be negated with a leading '-', e.g. -path:vendor or -ext:md.
+// Unknown keys and words without a colon are kept as search text, so patterns
+// like "std::vector" still work. A malformed value is reported as an error.
+func ParseQuery(input string) (Query, error) {
+	q := Query{MaxSize: -1}
+	var text []string
+	for _, field := range strings.Fields(input) {
+		key, value, ok := strings.Cut(field, ":")
+		negated := strings.HasPrefix(key, "-")
+		key = strings.TrimPrefix(key, "-")
+		if !ok || !isFilterKey(key) {
+			text = append(text, field)
+			continue
+		}
+		if value == "" {
+			return Query{}, fmt.Errorf("filter %q needs a value", field)
+		}
+		if negated && key != "ext" && key != "path" {
+			return Query{}, fmt.Errorf("filter %q can't be negated", field)
+		}
+
+		switch key {
+		case "ext":
+			ext := strings.ToLower(strings.TrimPrefix(value, "."))
+			if negated {
+				q.ExcludeExts = append(q.ExcludeExts, ext)
+			} else {
+				q.Exts = append(q.Exts, ext)
+			}
+		case "path":
+			if negated {
+				q.ExcludePaths = append(q.ExcludePaths, value)
+			} else {
+				q.Paths = append(q.Paths, value)
+			}
+		case "size":
+			if err := q.parseSize(value); err != nil {
+				return Query{}, err
+			}
+		case "changed":
+			if err := q.parseChanged(value); err != nil {
+				return Query{}, err
+			}
+		case "test":
+			switch strings.ToLower(value) {
+			case "yes", "y", "true", "only":
+				q.Tests = TestsOnly
+			case "no", "n", "false":
+				q.Tests = TestsExcluded
+			default:
+				return Query{}, fmt.Errorf("invalid test filter %q: use test:yes or test:no", value)
+			}
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// isFilterKey reports whether key names one of the filters understood by ParseQuery.
+func isFilterKey(key string) bool {
+	switch key {
+	case "ext", "path", "size", "changed", "test":
+		return true
+	}
+	return false
+}
+
+// HasFilters reports whether the query restricts the file list at all.
+func (q Query) HasFilters() bool {
+	return len(q.Exts) > 0 || len(q.ExcludeExts) > 0 || len(q.Paths) > 0 || len(q.ExcludePaths) > 0 ||
+		q.NeedsStat() || q.Tests != TestsIncluded
+}
+
+// NeedsStat reports whether Match looks at a file's size or modification time,
+// so callers can skip looking them up when it doesn't.
+func (q Query) NeedsStat() bool {
+	return q.MinSize > 0 || q.MaxSize >= 0 || q.ChangedWithin > 0 || q.ChangedBefore > 0
+}
+
+// Match reports whether a file passes every filter of the query. filePath is
+// slash-separated and relative to the project root; size and modTime are only
+// consulted when NeedsStat is true. now is the reference time for changed: filters.
+func (q Query) Match(filePath string, size int64, modTime time.Time, now time.Time) bool {
+	if len(q.Exts) > 0 || len(q.ExcludeExts) > 0 {
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(filePath), "."))
+		if len(q.Exts) > 0 && !slices.Contains(q.Exts, ext) {
+			return false
+		}
+		if slices.Contains(q.ExcludeExts, ext) {
+			return false
+		}
+	}
+	if len(q.Paths) > 0 && !containsSubstring(filePath, q.Paths) {
+		return false
+	}
+	if containsSubstring(filePath, q.ExcludePaths) {
+		return false
+	}
+	if q.MinSize > 0 && size < q.MinSize {
+		return false
+	}
+	if q.MaxSize >= 0 && size > q.MaxSize {
+		return false
+	}
+	if q.ChangedWithin > 0 && now.Sub(modTime) > q.ChangedWithin {
+		return false
+	}
+	if q.ChangedBefore > 0 && now.Sub(modTime) < q.ChangedBefore {
+		return false
+	}
+	switch q.Tests {
+	case TestsExcluded:
+		return !IsTestFile(filePath)
+	case TestsOnly:
+		return IsTestFile(filePath)
+	}
+	return true
+}
+
+// IsTestFile guesses whether a path holds tests, using the naming conventions
+// of common languages (foo_test.go, foo.spec.ts, test_foo.py, ...) and
+// well-known test directories.
+func IsTestFile(filePath string) bool {
+	for _, dir := range strings.Split(path.Dir(filePath), "/") {
+		switch dir {
+		case "test", "tests", "__tests__", "testdata", "spec":
+			return true
+		}
+	}
+	name := path.Base(filePath)
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.HasSuffix(stem, "_test") ||
+		strings.HasSuffix(stem, ".test") ||
+		strings.HasSuffix(stem, ".spec") ||
+		strings.HasPrefix(stem, "test_") ||
+		strings.HasSuffix(stem, "Test") ||
+		strings.HasSuffix(stem, "Tests")
+}
+
+// cutComparison splits a leading <, <=, > or >= off a filter value. op is
+// empty when value starts with none of them.
+func cutComparison(value string) (op, rest string) {
+	for _, op := range []string{"<=", ">=", "<", ">"} {
+		if rest, ok := strings.CutPrefix(value, op); ok {
+			return op, rest
+		}
+	}
+	return "", value
+}
+
+// parseSize applies a size: value such as "<20k", ">=1m" or ">512".
+func (q *Query) parseSize(value string) error {
+	op, rest := cutComparison(value)
+	if op == "" {
+		return fmt.Errorf("invalid size filter %q: start with <, >, <= or >=", value)
+	}
+	n, err := content.ParseSize(rest)
+	if err != nil {
+		return fmt.Errorf("invalid size filter %q: %w", value, err)
+	}
+	switch op {
+	case "<":
+		if n <= 0 {
+			// MaxSize can't express it: 0 still lets empty files through.
+			return fmt.Errorf("invalid size filter %q: no file is smaller than 0 bytes", value)
+		}
+		q.MaxSize = n - 1
+	case "<=":
+		q.MaxSize = n
+	case ">":
+		q.MinSize = n + 1
+	case ">=":
+		q.MinSize = n
+	}
+	return nil
+}
+
+// parseChanged applies a changed: value such as "7d", "<12h" or ">2w". It
+// takes the same comparisons as size:; as ages are never exactly equal, <=
+// means the same as < (and no comparison), and >= the same as >.
+func (q *Query) parseChanged(value string) error {
+	op, rest := cutComparison(value)
+	d, err := parseAge(rest)
+	if err != nil {
+		return fmt.Errorf("invalid changed filter %q: %w", value, err)
+	}
+	if op == ">" || op == ">=" {
+		q.ChangedBefore = d
+	} else {
+		q.ChangedWithin = d
+	}
+	return nil
+}
+
+// parseAge parses a duration like "30m", "12h", "7d" or "2w". A bare number means days.
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("missing duration")
+	}
+	unit := time.Duration(0)
+	switch s[len(s)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	}
+	if unit == 0 {
+		unit = 24 * time.Hour
+	} else {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a duration (use m, h, d or w)", s)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
+
+// containsSubstring reports whether s contains any of the substrings.
+func containsSubstring(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
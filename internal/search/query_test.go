@@ -0,0 +1,137 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	const day = 24 * time.Hour
+	tests := []struct {
+		input string
+		want  Query
+	}{
+		{"", Query{MaxSize: -1}},
+		{"  model   view ", Query{Text: "model view", MaxSize: -1}},
+		{"model ext:go ext:.MOD", Query{Text: "model", Exts: []string{"go", "mod"}, MaxSize: -1}},
+		{"-ext:md -path:vendor path:internal/", Query{ExcludeExts: []string{"md"}, ExcludePaths: []string{"vendor"}, Paths: []string{"internal/"}, MaxSize: -1}},
+		{"size:<20k", Query{MaxSize: 20*1024 - 1}},
+		{"size:<=20k", Query{MaxSize: 20 * 1024}},
+		{"size:>1m", Query{MinSize: 1<<20 + 1, MaxSize: -1}},
+		{"size:>=512", Query{MinSize: 512, MaxSize: -1}},
+		{"size:<=0", Query{MaxSize: 0}}, // Only empty files
+		{"changed:7d", Query{ChangedWithin: 7 * day, MaxSize: -1}},
+		{"changed:<12h", Query{ChangedWithin: 12 * time.Hour, MaxSize: -1}},
+		{"changed:>2w", Query{ChangedBefore: 14 * day, MaxSize: -1}},
+		{"changed:<=12h", Query{ChangedWithin: 12 * time.Hour, MaxSize: -1}},
+		{"changed:>=2w", Query{ChangedBefore: 14 * day, MaxSize: -1}},
+		{"changed:3", Query{ChangedWithin: 3 * day, MaxSize: -1}},
+		{"changed:30m", Query{ChangedWithin: 30 * time.Minute, MaxSize: -1}},
+		{"test:no", Query{Tests: TestsExcluded, MaxSize: -1}},
+		{"test:Yes", Query{Tests: TestsOnly, MaxSize: -1}},
+		// Unknown keys and words without a colon stay search text.
+		{"std::vector foo:bar", Query{Text: "std::vector foo:bar", MaxSize: -1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.input)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, input := range []string{
+		"ext:",       // No value
+		"-size:<20k", // Only ext and path can be negated
+		"size:20k",   // No comparison
+		"size:<lots", // Not a size
+		"size:=<20k", // Unknown comparison
+		"size:<0",    // Nothing is smaller than nothing
+		"size:<0k",
+		"changed:soon",  // Not a duration
+		"changed:-3d",   // Negative
+		"changed:>",     // Missing duration
+		"changed:<>3d",  // Two comparisons
+		"test:sometime", // Neither yes nor no
+	} {
+		if q, err := ParseQuery(input); err == nil {
+			t.Errorf("ParseQuery(%q) = %+v, want an error", input, q)
+		}
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query   string
+		path    string
+		size    int64
+		changed time.Duration // How long before now the file was modified
+		want    bool
+	}{
+		{"", "any/file.txt", 0, 0, true},
+		{"ext:go", "main.go", 0, 0, true},
+		{"ext:go", "main.GO", 0, 0, true},
+		{"ext:go", "README.md", 0, 0, false},
+		{"ext:go ext:md", "README.md", 0, 0, true}, // Same kind: ORed
+		{"-ext:md", "README.md", 0, 0, false},
+		{"path:internal/ ext:go", "internal/a.go", 0, 0, true},
+		{"path:internal/ ext:go", "internal/a.md", 0, 0, false}, // Different kinds: ANDed
+		{"-path:vendor", "vendor/x/y.go", 0, 0, false},
+		{"size:<20k", "a", 20*1024 - 1, 0, true},
+		{"size:<20k", "a", 20 * 1024, 0, false},
+		{"size:>=1k", "a", 1024, 0, true},
+		{"size:>1k", "a", 1024, 0, false},
+		{"size:<=0", "a", 0, 0, true},
+		{"size:<=0", "a", 1, 0, false},
+		{"changed:7d", "a", 0, 6 * 24 * time.Hour, true},
+		{"changed:7d", "a", 0, 8 * 24 * time.Hour, false},
+		{"changed:>7d", "a", 0, 8 * 24 * time.Hour, true},
+		{"changed:>7d", "a", 0, time.Hour, false},
+		{"changed:>=7d", "a", 0, 8 * 24 * time.Hour, true},
+		{"changed:<=7d", "a", 0, 8 * 24 * time.Hour, false},
+		{"test:no", "pkg/foo_test.go", 0, 0, false},
+		{"test:no", "pkg/foo.go", 0, 0, true},
+		{"test:yes", "pkg/foo.go", 0, 0, false},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) failed: %v", tt.query, err)
+		}
+		if got := q.Match(tt.path, tt.size, now.Add(-tt.changed), now); got != tt.want {
+			t.Errorf("ParseQuery(%q).Match(%q, size %d, changed %s ago) = %v, want %v",
+				tt.query, tt.path, tt.size, tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"internal/search/query_test.go", true},
+		{"web/app.spec.ts", true},
+		{"web/app.test.js", true},
+		{"tests/helpers.py", true},
+		{"pkg/test_parser.py", true},
+		{"src/main/java/ParserTest.java", true},
+		{"src/__tests__/app.js", true},
+		{"internal/search/testdata/input.txt", true},
+		{"internal/search/query.go", false},
+		{"cmd/latest.go", false},
+		{"contest/entry.go", false},
+	}
+	for _, tt := range tests {
+		if got := IsTestFile(tt.path); got != tt.want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
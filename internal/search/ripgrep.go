@@ -2,10 +2,12 @@ package search
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os" // Added for os.PathSeparator
+	"os"
 	"os/exec"
-	"path/filepath" // Added for filepath.Clean
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -20,24 +22,39 @@ type RipgrepMatch struct {
 	Match string // The exact string that matched the pattern
 }
 
+// maxColumns is the line length above which ripgrep replaces the matched line with a preview.
+const maxColumns = 500
+
 // RunRipgrep executes the 'rg' (ripgrep) command with the given pattern and directory.
 // It returns a slice of RipgrepMatch objects if successful, or an error otherwise.
 // The --vimgrep flag is used to get structured output in the format: file:line:col:text.
 func RunRipgrep(pattern string, dir string) ([]RipgrepMatch, error) {
+	return RunRipgrepContext(context.Background(), pattern, dir)
+}
+
+// RunRipgrepContext is like RunRipgrep, but kills the ripgrep process and
+// returns ctx.Err() if ctx is cancelled before it finishes.
+func RunRipgrepContext(ctx context.Context, pattern string, dir string) ([]RipgrepMatch, error) {
 	// Construct the ripgrep command with necessary flags for structured output.
 	// -n: show line number
-	// -o: show offset (column number)
-	// --vimgrep: output in vimgrep format (file:line:col:match_text)
+	// --vimgrep: output in vimgrep format (file:line:col:line_text), one line per match
 	// --no-messages: suppress ripgrep's informational messages (e.g., binary file warnings)
-	// --max-columns-preview: shows a preview of long lines instead of truncating them.
+	// --max-columns/--max-columns-preview: shows a preview of long lines instead of dropping them.
 	// --color=never: disables color output to ensure consistent parsing.
-	cmd := exec.Command("rg", "-n", "-o", "--vimgrep", "--no-messages", "--max-columns-preview", "--color=never", pattern, dir)
+	// -e: pass the pattern explicitly so a query starting with '-' is not read as a flag.
+	cmd := exec.CommandContext(ctx, "rg", "-n", "--vimgrep", "--no-messages",
+		"--max-columns", strconv.Itoa(maxColumns), "--max-columns-preview", "--color=never",
+		"-e", pattern, dir)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout // Capture standard output
 	cmd.Stderr = &stderr // Capture standard error
 
 	err := cmd.Run() // Execute the command
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// The search was superseded; whatever ripgrep printed before being killed is incomplete.
+		return nil, ctxErr
+	}
 	if err != nil {
 		// ripgrep exits with status 1 if no matches are found. This is not an error in our context.
 		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
@@ -48,6 +65,10 @@ func RunRipgrep(pattern string, dir string) ([]RipgrepMatch, error) {
 		return nil, fmt.Errorf("ripgrep command failed: %v\nStderr: %s", err, stderr.String())
 	}
 
+	// ripgrep and Go both use RE2-style syntax, so the pattern can usually be compiled
+	// locally to recover the exact matched substring. If it can't, Match falls back to Text.
+	re, reErr := regexp.Compile(pattern)
+
 	var matches []RipgrepMatch
 	// Split the output into individual lines.
 	lines := strings.Split(stdout.String(), "\n")
@@ -71,28 +92,40 @@ func RunRipgrep(pattern string, dir string) ([]RipgrepMatch, error) {
 		if err != nil {
 			continue
 		}
-		matchText := parts[3]
+		lineText := strings.TrimRight(parts[3], "\r")
 
-		// --- IMPORTANT FIX: Normalize file path ---
-		// If the file path returned by ripgrep starts with the base directory
-		// (which happens if ripgrep returns absolute paths or paths relative to
-		// the root but containing our project root), make it truly relative.
+		// Paths are reported relative to dir, but ripgrep can also print them
+		// with dir in front (e.g. when given absolute paths); strip it so every
+		// match carries a path relative to the project root, like the index.
 		cleanDir := filepath.Clean(dir)
 		if strings.HasPrefix(file, cleanDir) {
 			file = strings.TrimPrefix(file, cleanDir)
 			// Remove any leading path separator that might remain after trimming the prefix
 			file = strings.TrimPrefix(file, string(os.PathSeparator))
 		}
-		// --- END IMPORTANT FIX ---
 
 		matches = append(matches, RipgrepMatch{
 			File:  file,
 			Line:  lineNum,
 			Col:   colNum,
-			Text:  matchText,
-			Match: matchText,
+			Text:  lineText,
+			Match: matchedSubstring(re, reErr, lineText, colNum),
 		})
 	}
 
 	return matches, nil
 }
+
+// matchedSubstring returns the part of text matched by re starting at the 1-based
+// byte column col. It returns text itself when the pattern could not be compiled
+// or does not match at that column (e.g. for a truncated preview line).
+func matchedSubstring(re *regexp.Regexp, reErr error, text string, col int) string {
+	if reErr != nil || col < 1 || col > len(text) {
+		return text
+	}
+	loc := re.FindStringIndex(text[col-1:])
+	if loc == nil || loc[0] != 0 || loc[1] == 0 {
+		return text
+	}
+	return text[col-1 : col-1+loc[1]]
+}
@@ -0,0 +1,232 @@
+package symbols
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"prompty/internal/content"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ctagsKinds maps universal-ctags kind names to symbol kinds. Both the long
+// names (written with --fields=+K) and the default one-letter names are
+// understood; letters mean different things in different languages, so the
+// long names are more reliable. Kinds not listed (variables, fields, imports,
+// ...) are skipped.
+var ctagsKinds = map[string]Kind{
+	"function": Func, "func": Func, "f": Func,
+	"method": Method, "singletonMethod": Method, "member": Method, "m": Method,
+	"class": Type, "struct": Type, "interface": Type, "trait": Type,
+	"enum": Type, "typedef": Type, "type": Type, "union": Type,
+	"c": Type, "s": Type, "i": Type, "g": Type, "t": Type,
+	"constant": Const, "const": Const, "macro": Const, "define": Const, "d": Const,
+}
+
+// cFamilyExts are the extensions of languages where ctags' "member" (m) kind
+// is a data member rather than a method; such tags are skipped.
+var cFamilyExts = map[string]bool{".c": true, ".h": true, ".cc": true, ".cpp": true, ".cxx": true, ".hh": true, ".hpp": true, ".cs": true}
+
+// ctagsScopes are the extension fields that name the scope a tag is declared
+// in, e.g. class:Server. Methods are named Scope.method after them.
+var ctagsScopes = []string{"class", "struct", "interface", "impl", "module", "namespace", "enum", "trait"}
+
+// ctagsTag is a parsed line of a tags file.
+type ctagsTag struct {
+	name    string
+	path    string // Relative to the project root, slash-separated
+	scope   string // Innermost enclosing class, struct, ... if any
+	kind    Kind
+	pattern string // Search pattern from the address, when there is no line number
+	line    int    // 0 until resolved from the pattern
+	end     int    // 0 when the tags file has no end: field
+}
+
+// readTagsFile reads a universal-ctags tags file and returns the symbols it
+// lists for files other than Go files, keyed by path relative to root. Tags
+// without a line number are located by searching their file for the tag's
+// pattern, and tags without an end: field (add --fields=+ne to the ctags
+// command line for exact ranges) are assumed to run until the next tag.
+func readTagsFile(root, tagsPath string) (map[string][]Symbol, error) {
+	f, err := os.Open(tagsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	byFile := make(map[string][]ctagsTag)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		tag, ok := parseTagLine(scanner.Text(), root)
+		if ok {
+			byFile[tag.path] = append(byFile[tag.path], tag)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	symbols := make(map[string][]Symbol, len(byFile))
+	for relPath, tags := range byFile {
+		if syms := resolveTags(filepath.Join(root, filepath.FromSlash(relPath)), tags); len(syms) > 0 {
+			symbols[relPath] = syms
+		}
+	}
+	return symbols, nil
+}
+
+// parseTagLine parses one line of a tags file:
+//
+//	name<TAB>file<TAB>address;"<TAB>kind<TAB>key:value...
+//
+// It reports false for comment lines, Go files and kinds that aren't symbols.
+func parseTagLine(line, root string) (ctagsTag, bool) {
+	if strings.HasPrefix(line, "!_") {
+		return ctagsTag{}, false // Pseudo-tags describing the file itself
+	}
+	name, rest, ok1 := strings.Cut(line, "\t")
+	file, rest, ok2 := strings.Cut(rest, "\t")
+	if !ok1 || !ok2 || name == "" {
+		return ctagsTag{}, false
+	}
+
+	if filepath.IsAbs(file) {
+		rel, err := filepath.Rel(root, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return ctagsTag{}, false
+		}
+		file = rel
+	}
+	file = path.Clean(filepath.ToSlash(file))
+	if path.Ext(file) == ".go" {
+		return ctagsTag{}, false // go/parser does a better job for Go
+	}
+
+	address, fieldList, _ := strings.Cut(rest, ";\"\t")
+	address = strings.TrimSuffix(address, ";\"")
+
+	tag := ctagsTag{name: name, path: file, kind: -1}
+	if n, err := strconv.Atoi(address); err == nil {
+		tag.line = n
+	} else {
+		tag.pattern = unescapeTagPattern(address)
+	}
+
+	var scope string
+	for i, field := range strings.Split(fieldList, "\t") {
+		key, value, hasColon := strings.Cut(field, ":")
+		switch {
+		case !hasColon && i == 0:
+			value, key = key, "kind" // The kind can be written bare as the first field
+			fallthrough
+		case key == "kind":
+			if kind, ok := ctagsKinds[value]; ok && !(kind == Method && value != "method" && cFamilyExts[path.Ext(file)]) {
+				tag.kind = kind
+			}
+		case key == "line":
+			tag.line, _ = strconv.Atoi(value)
+		case key == "end":
+			tag.end, _ = strconv.Atoi(value)
+		case key == "scope":
+			_, scope, _ = strings.Cut(value, ":") // scope:class:Server with --fields=+Z
+		default:
+			for _, s := range ctagsScopes {
+				if key == s {
+					scope = value
+				}
+			}
+		}
+	}
+	if tag.kind < 0 {
+		return ctagsTag{}, false
+	}
+	// Nested scopes are written Outer::Inner or Outer.Inner; keep the innermost.
+	tag.scope = scope[strings.LastIndexAny(scope, ":.")+1:]
+	if tag.kind == Method && tag.scope != "" {
+		tag.name = tag.scope + "." + tag.name
+	}
+	return tag, true
+}
+
+// unescapeTagPattern turns a tag address like /^func main() {$/ into the text
+// of the line it searches for.
+func unescapeTagPattern(address string) string {
+	if len(address) < 2 || (address[0] != '/' && address[0] != '?') {
+		return ""
+	}
+	p := address[1 : len(address)-1]
+	p = strings.TrimPrefix(p, "^")
+	p = strings.TrimSuffix(p, "$")
+	return strings.NewReplacer(`\/`, `/`, `\?`, `?`, `\\`, `\`).Replace(p)
+}
+
+// resolveTags fills in missing line numbers and ends for the tags of one file
+// and converts them to symbols. The file is only read when something is missing.
+func resolveTags(absPath string, tags []ctagsTag) []Symbol {
+	needsFile := false
+	for _, tag := range tags {
+		if tag.line == 0 || tag.end == 0 {
+			needsFile = true
+			break
+		}
+	}
+
+	lineCount := 0
+	if needsFile {
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil // Stale tags file: the file is gone
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		lineCount = len(lines)
+		for i := range tags {
+			if tags[i].line == 0 && tags[i].pattern != "" {
+				tags[i].line = findLine(lines, tags[i].pattern)
+			}
+		}
+	}
+
+	var resolved []ctagsTag
+	for _, tag := range tags {
+		if tag.line > 0 {
+			resolved = append(resolved, tag)
+		}
+	}
+	sort.SliceStable(resolved, func(i, j int) bool { return resolved[i].line < resolved[j].line })
+
+	syms := make([]Symbol, 0, len(resolved))
+	for i, tag := range resolved {
+		end := tag.end
+		if end == 0 {
+			// Without an end: field, guess that the declaration runs until the
+			// next one, not counting its own members.
+			end = lineCount
+			for _, next := range resolved[i+1:] {
+				if next.line > tag.line && next.scope != tag.name {
+					end = next.line - 1
+					break
+				}
+			}
+		}
+		syms = append(syms, Symbol{
+			Name:  tag.name,
+			Kind:  tag.kind,
+			Path:  tag.path,
+			Lines: content.LineRange{Start: tag.line, End: max(end, tag.line)},
+		})
+	}
+	return syms
+}
+
+// findLine returns the 1-based number of the first line starting with pattern, or 0.
+func findLine(lines []string, pattern string) int {
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSuffix(line, "\r"), pattern) {
+			return i + 1
+		}
+	}
+	return 0
+}
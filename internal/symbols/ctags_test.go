@@ -0,0 +1,105 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"prompty/internal/content"
+	"testing"
+)
+
+func TestParseTagLine(t *testing.T) {
+	root := filepath.FromSlash("/src/app")
+	tests := []struct {
+		name string
+		line string
+		want ctagsTag
+		ok   bool
+	}{
+		{
+			name: "line number and end",
+			line: "Server\tsrc/server.py\t12;\"\tkind:class\tline:12\tend:40",
+			want: ctagsTag{name: "Server", path: "src/server.py", kind: Type, line: 12, end: 40},
+			ok:   true,
+		},
+		{
+			name: "bare kind and pattern address",
+			line: "main\tmain.c\t/^int main(int argc, char \\/*argv[]) {$/;\"\tf",
+			want: ctagsTag{name: "main", path: "main.c", kind: Func, pattern: "int main(int argc, char /*argv[]) {"},
+			ok:   true,
+		},
+		{
+			name: "method named after its scope",
+			line: "start\tlib/app.rb\t7;\"\tkind:method\tscope:class:App::Server",
+			want: ctagsTag{name: "Server.start", path: "lib/app.rb", scope: "Server", kind: Method, line: 7},
+			ok:   true,
+		},
+		{
+			name: "absolute path under the root",
+			line: filepath.Join(root, "x.js") + "\t" + filepath.Join(root, "lib", "x.js") + "\t3;\"\tfunction",
+			want: ctagsTag{name: filepath.Join(root, "x.js"), path: "lib/x.js", kind: Func, line: 3},
+			ok:   true,
+		},
+		{name: "pseudo-tag", line: "!_TAG_FILE_FORMAT\t2\t/extended format/"},
+		{name: "Go file", line: "main\tmain.go\t3;\"\tfunc"},
+		{name: "variable", line: "count\tx.py\t3;\"\tkind:variable"},
+		{name: "C data member", line: "len\tbuf.h\t9;\"\tm\tstruct:buf"},
+		{name: "outside the root", line: "f\t/elsewhere/x.js\t3;\"\tfunction"},
+		{name: "truncated", line: "name-only"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseTagLine(tt.line, root)
+			if ok != tt.ok {
+				t.Fatalf("parseTagLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseTagLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTags(t *testing.T) {
+	src := `class Server:
+    def start(self):
+        pass
+
+    def stop(self):
+        pass
+
+def main():
+    Server().start()
+`
+	absPath := filepath.Join(t.TempDir(), "server.py")
+	if err := os.WriteFile(absPath, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := []ctagsTag{
+		{name: "main", path: "server.py", kind: Func, pattern: "def main():"},
+		{name: "Server", path: "server.py", kind: Type, pattern: "class Server:"},
+		{name: "Server.start", path: "server.py", scope: "Server", kind: Method, line: 2},
+		{name: "Server.stop", path: "server.py", scope: "Server", kind: Method, line: 5, end: 6},
+		{name: "gone", path: "server.py", kind: Func, pattern: "def gone():"}, // Stale: not in the file
+	}
+	want := []Symbol{
+		{Name: "Server", Kind: Type, Path: "server.py", Lines: content.LineRange{Start: 1, End: 7}}, // Its methods don't end it
+		{Name: "Server.start", Kind: Method, Path: "server.py", Lines: content.LineRange{Start: 2, End: 4}},
+		{Name: "Server.stop", Kind: Method, Path: "server.py", Lines: content.LineRange{Start: 5, End: 6}},
+		{Name: "main", Kind: Func, Path: "server.py", Lines: content.LineRange{Start: 8, End: 9}},
+	}
+
+	got := resolveTags(absPath, tags)
+	if len(got) != len(want) {
+		t.Fatalf("resolveTags() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resolveTags()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := resolveTags(filepath.Join(t.TempDir(), "missing.py"), tags); got != nil {
+		t.Errorf("resolveTags() for a missing file = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,97 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"prompty/internal/content"
+)
+
+// parseGoFile extracts the top-level declarations of the Go file at absPath,
+// recording them under relPath. On a syntax error it returns whatever was
+// declared before the error along with the error.
+func parseGoFile(absPath, relPath string) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, absPath, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil {
+		return nil, err
+	}
+
+	// lines returns the range from the doc comment (if any) to the end of node.
+	lines := func(doc *ast.CommentGroup, node ast.Node) content.LineRange {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return content.LineRange{Start: fset.Position(start).Line, End: fset.Position(node.End()).Line}
+	}
+
+	var syms []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := Symbol{Name: d.Name.Name, Kind: Func, Path: relPath, Lines: lines(d.Doc, d)}
+			if recv := receiverName(d); recv != "" {
+				sym.Name = recv + "." + sym.Name
+				sym.Kind = Method
+			}
+			syms = append(syms, sym)
+
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE && d.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range d.Specs {
+				// An ungrouped declaration takes its doc comment and keyword along;
+				// in a group ("const ( ... )") only the spec's own lines are used.
+				doc, node := d.Doc, ast.Node(d)
+				if d.Lparen.IsValid() {
+					node = spec
+				}
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if d.Lparen.IsValid() {
+						doc = s.Doc
+					}
+					syms = append(syms, Symbol{Name: s.Name.Name, Kind: Type, Path: relPath, Lines: lines(doc, node)})
+				case *ast.ValueSpec:
+					if d.Lparen.IsValid() {
+						doc = s.Doc
+					}
+					for _, name := range s.Names {
+						if name.Name == "_" {
+							continue
+						}
+						syms = append(syms, Symbol{Name: name.Name, Kind: Const, Path: relPath, Lines: lines(doc, node)})
+					}
+				}
+			}
+		}
+	}
+	return syms, err
+}
+
+// receiverName returns the name of the type a method is declared on, without
+// pointer or type parameters, or "" for plain functions.
+func receiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr: // T[K]
+			expr = e.X
+		case *ast.IndexListExpr: // T[K, V]
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
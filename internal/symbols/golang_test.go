@@ -0,0 +1,75 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"prompty/internal/content"
+	"testing"
+)
+
+func TestParseGoFile(t *testing.T) {
+	src := `package demo
+
+// Limit caps things.
+const Limit = 10
+
+const (
+	// A is first.
+	A = iota
+	_
+	B
+)
+
+// Server serves.
+type Server struct{}
+
+// Start starts s.
+func (s *Server) Start() {
+}
+
+func (c Cache[K, V]) Get(k K) V { var v V; return v }
+
+func helper() {}
+`
+	absPath := filepath.Join(t.TempDir(), "demo.go")
+	if err := os.WriteFile(absPath, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseGoFile(absPath, "pkg/demo.go")
+	if err != nil {
+		t.Fatalf("parseGoFile() failed: %v", err)
+	}
+	lines := func(start, end int) content.LineRange { return content.LineRange{Start: start, End: end} }
+	want := []Symbol{
+		{Name: "Limit", Kind: Const, Path: "pkg/demo.go", Lines: lines(3, 4)},
+		{Name: "A", Kind: Const, Path: "pkg/demo.go", Lines: lines(7, 8)}, // Only its own spec in a group
+		{Name: "B", Kind: Const, Path: "pkg/demo.go", Lines: lines(10, 10)},
+		{Name: "Server", Kind: Type, Path: "pkg/demo.go", Lines: lines(13, 14)},
+		{Name: "Server.Start", Kind: Method, Path: "pkg/demo.go", Lines: lines(16, 18)},
+		{Name: "Cache.Get", Kind: Method, Path: "pkg/demo.go", Lines: lines(20, 20)},
+		{Name: "helper", Kind: Func, Path: "pkg/demo.go", Lines: lines(22, 22)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseGoFile() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseGoFile()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseGoFileSyntaxError(t *testing.T) {
+	absPath := filepath.Join(t.TempDir(), "broken.go")
+	if err := os.WriteFile(absPath, []byte("package x\n\nfunc ok() {}\n\nfunc broken( {\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseGoFile(absPath, "broken.go")
+	if err == nil {
+		t.Error("parseGoFile() of a broken file succeeded, want an error")
+	}
+	if len(got) == 0 || got[0].Name != "ok" {
+		t.Errorf("parseGoFile() = %+v, want the declarations before the error", got)
+	}
+}
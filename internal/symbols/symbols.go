@@ -0,0 +1,204 @@
+// Package symbols finds the declarations (functions, methods, types and
+// constants) in a project, so single declarations can be searched for and
+// tagged. Go files are parsed with go/parser; other languages are read from a
+// universal-ctags "tags" file at the project root, when there is one.
+package symbols
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"prompty/internal/content"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Kind is the sort of declaration a Symbol is.
+type Kind int
+
+const (
+	Func   Kind = iota // 0: A function
+	Method             // 1: A function bound to a type
+	Type               // 2: A type, struct, class, interface or enum
+	Const              // 3: A constant (or a C macro)
+)
+
+// String returns a short label for the kind, as shown next to search results.
+func (k Kind) String() string {
+	switch k {
+	case Method:
+		return "method"
+	case Type:
+		return "type"
+	case Const:
+		return "const"
+	default:
+		return "func"
+	}
+}
+
+// Symbol is one declaration in a file.
+type Symbol struct {
+	Name  string            // Declared name; methods are written Receiver.Name
+	Kind  Kind              // What was declared
+	Path  string            // Slash-separated path of the file, relative to the project root
+	Lines content.LineRange // Lines the declaration spans, including its doc comment
+}
+
+// File is a candidate file for symbol extraction.
+type File struct {
+	Path    string    // Slash-separated path relative to the project root
+	Size    int64     // Size in bytes; larger than maxGoFileSize skips parsing
+	ModTime time.Time // Modification time, used to tell when cached symbols are stale
+}
+
+// TagsFileName is the name of the ctags file looked for at the project root.
+const TagsFileName = "tags"
+
+// maxGoFileSize is the largest Go file that gets parsed. Bigger ones are
+// almost always generated and would only slow searches down.
+const maxGoFileSize = content.DefaultMaxSize
+
+// Table extracts and caches the symbols of a project. Go files are only
+// re-parsed when their modification time changes, and the tags file only when
+// it is rewritten, so repeated searches are cheap. It is safe for concurrent use.
+type Table struct {
+	root   string
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	goFiles map[string]goFile   // Parsed Go files by path
+	tags    map[string][]Symbol // Symbols from the tags file, by path
+	tagsMod time.Time           // Modification time of the tags file when it was read
+}
+
+// goFile is the cached result of parsing one Go file.
+type goFile struct {
+	modTime time.Time
+	symbols []Symbol
+}
+
+// NewTable creates an empty symbol table for the project at root.
+func NewTable(root string, logger *slog.Logger) *Table {
+	return &Table{
+		root:    root,
+		logger:  logger,
+		goFiles: make(map[string]goFile),
+	}
+}
+
+// Symbols returns the symbols declared in files, which should be every file of
+// the project: from go/parser for Go files, and from the tags file for
+// everything else. Files missing from files contribute nothing, even if the
+// tags file lists them. It returns ctx.Err() if ctx is cancelled while parsing.
+func (t *Table) Symbols(ctx context.Context, files []File) ([]Symbol, error) {
+	tags := t.loadTags()
+
+	var stale []File
+	var syms []Symbol
+	t.mu.Lock()
+	live := make(map[string]bool)
+	for _, f := range files {
+		if path.Ext(f.Path) != ".go" {
+			syms = append(syms, tags[f.Path]...)
+			continue
+		}
+		live[f.Path] = true
+		if cached, ok := t.goFiles[f.Path]; ok && cached.modTime.Equal(f.ModTime) {
+			syms = append(syms, cached.symbols...)
+		} else if f.Size <= maxGoFileSize {
+			stale = append(stale, f)
+		}
+	}
+	t.mu.Unlock()
+
+	parsed, err := t.parseGoFiles(ctx, stale)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, f := range stale {
+		t.goFiles[f.Path] = goFile{modTime: f.ModTime, symbols: parsed[i]}
+		syms = append(syms, parsed[i]...)
+	}
+	// Drop cached files that are no longer in the project, so the cache doesn't grow forever.
+	for p := range t.goFiles {
+		if !live[p] {
+			delete(t.goFiles, p)
+		}
+	}
+	if len(stale) > 0 {
+		t.logger.Debug("Parsed Go files for symbols", "files", len(stale))
+	}
+	return syms, nil
+}
+
+// parseGoFiles parses files in parallel, returning their symbols in the same order.
+func (t *Table) parseGoFiles(ctx context.Context, files []File) ([][]Symbol, error) {
+	results := make([][]Symbol, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), max(len(files), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				syms, err := parseGoFile(t.abs(files[i].Path), files[i].Path)
+				if err != nil {
+					// Partial results are still useful; a syntax error shouldn't hide the rest of the file.
+					t.logger.Debug("Go file has errors", "path", files[i].Path, "err", err)
+				}
+				results[i] = syms
+			}
+		}()
+	}
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// loadTags returns the symbols from the project's tags file, re-reading the file
+// if it changed since the last call. A missing tags file yields no symbols.
+func (t *Table) loadTags() map[string][]Symbol {
+	tagsPath := t.abs(TagsFileName)
+	info, err := os.Stat(tagsPath)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.tags, t.tagsMod = nil, time.Time{}
+		return nil
+	}
+	if !t.tagsMod.IsZero() && info.ModTime().Equal(t.tagsMod) {
+		return t.tags
+	}
+
+	started := time.Now()
+	tags, err := readTagsFile(t.root, tagsPath)
+	if err != nil {
+		t.logger.Warn("Reading the tags file failed", "path", tagsPath, "err", err)
+		return t.tags
+	}
+	t.tags, t.tagsMod = tags, info.ModTime()
+	t.logger.Info("Loaded tags file", "files", len(tags), "took", time.Since(started))
+	return tags
+}
+
+// abs converts a path relative to the project root into an absolute path.
+func (t *Table) abs(relPath string) string {
+	return filepath.Join(t.root, filepath.FromSlash(relPath))
+}
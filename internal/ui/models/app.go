@@ -2,7 +2,10 @@ package models
 
 import (
 	"fmt"
-	"log" // Added log for debugging
+	"log/slog"
+	"prompty/internal/content"
+	"prompty/internal/index"
+	"prompty/internal/logging"
 	"prompty/internal/ui/styles"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -29,6 +32,37 @@ type UntagFileMsg struct {
 	Path string
 }
 
+// UntagGroupMsg is sent from BrowseModel to App to untag every file that was
+// tagged together as a directory or glob group (see FileItem.Group).
+type UntagGroupMsg struct {
+	Group string
+}
+
+// Options holds the settings given on the command line.
+type Options struct {
+	// Dir is the project root to search. Empty means the current directory.
+	Dir string
+	// Query pre-fills the search input; it is searched once the file index is ready.
+	Query string
+	// Tags lists files (relative to Dir) to tag at startup.
+	Tags []string
+	// MaxFileSize is the largest file (in bytes) whose content is included in the
+	// prompt; bigger files are replaced by a placeholder. 0 means no limit.
+	MaxFileSize int64
+	// Base is the branch the Ctrl+G branch scope compares against. Empty picks
+	// origin's default branch, main or master.
+	Base string
+	// RecentCommits is how many commits the Ctrl+G recent scope looks back.
+	RecentCommits int
+	// Logger receives the application's log records. Nil discards them.
+	Logger *slog.Logger
+}
+
+// DefaultOptions returns the settings used when no flags are given.
+func DefaultOptions() Options {
+	return Options{MaxFileSize: content.DefaultMaxSize, RecentCommits: index.DefaultRecentCommits}
+}
+
 // App is the main application model that holds the state of the entire CLI tool.
 // It manages the different sub-models (Search, Browse, Compose) and their interactions.
 type App struct {
@@ -36,6 +70,8 @@ type App struct {
 	width  int      // Current terminal width
 	height int      // Current terminal height
 
+	logger *slog.Logger // Structured logger shared (with a per-model "model" field) by all models
+
 	searchModel  *SearchModel  // Model for the search functionality (now with integrated browsing)
 	browseModel  *BrowseModel  // Model for reviewing tagged files
 	composeModel *ComposeModel // Model for prompt composition
@@ -47,21 +83,31 @@ type App struct {
 
 // NewApp creates and initializes a new App model.
 // It sets the initial state to Search and initializes all sub-models.
-func NewApp() *App {
+func NewApp(opts Options) *App {
+	if opts.Logger == nil {
+		opts.Logger = logging.Discard()
+	}
 	return &App{
-		state:        SearchState,       // Start in the Search state
-		searchModel:  NewSearchModel(),  // Initialize SearchModel
-		browseModel:  NewBrowseModel(),  // Initialize BrowseModel
-		composeModel: NewComposeModel(), // Initialize ComposeModel
+		state:        SearchState,                                                    // Start in the Search state
+		logger:       opts.Logger.With(logging.KeyModel, "app"),                      // Logger for App's own records
+		searchModel:  NewSearchModel(opts),                                           // Initialize SearchModel
+		browseModel:  NewBrowseModel(opts.Logger.With(logging.KeyModel, "browse")),   // Initialize BrowseModel
+		composeModel: NewComposeModel(opts.Logger.With(logging.KeyModel, "compose")), // Initialize ComposeModel
 		// Initializing slice to empty, not nil, for safety
 		currentTaggedFiles: []FileItem{},
 	}
 }
 
 // Init initializes the application.
-// For the main app, there are no initial commands to run.
+// It runs the sub-models' initial commands, e.g. loading the file list for search.
 func (m *App) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.searchModel.Init(), m.browseModel.Init(), m.composeModel.Init())
+}
+
+// Close releases resources held by the sub-models. It should be called once
+// the Bubble Tea program has exited.
+func (m *App) Close() error {
+	return m.searchModel.Close()
 }
 
 // Update handles messages for the main App model.
@@ -76,13 +122,35 @@ func (m *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// When the terminal window size changes, update the app's dimensions.
 		m.width = msg.Width
 		m.height = msg.Height
-		// No need to pass this down directly to sub-models unless they specifically
-		// need to react to window size changes (e.g., for dynamic layout adjustments).
-		return m, nil
+		// The search and browse tabs lay out a list and a preview side by side, so
+		// they need the room they get inside the frame rather than the terminal size.
+		var searchModel, browseModel tea.Model
+		var browseCmd tea.Cmd
+		searchModel, cmd = m.searchModel.Update(m.contentSize())
+		m.searchModel = searchModel.(*SearchModel)
+		browseModel, browseCmd = m.browseModel.Update(m.contentSize())
+		m.browseModel = browseModel.(*BrowseModel)
+		return m, tea.Batch(cmd, browseCmd)
 
 	case tea.KeyMsg:
 		// Handle global key presses (like Ctrl+C for quit, or tab navigation).
 		// IMPORTANT: Ensure Ctrl+A does NOT lead to a quit here.
+		if m.state == BrowseState && m.browseModel.Typing() && msg.String() != "ctrl+c" {
+			// A search or line number is being typed into the preview; digits and
+			// Tab are part of it rather than tab switches.
+			break
+		}
+		if m.state == SearchState && m.searchModel.Typing() {
+			// Digits are part of the query (size:<20k, lines:>100) or of a dialog's
+			// input. The query has no use for Tab, which stays the way out of the tab,
+			// but an open dialog keeps it like every other key.
+			key := msg.String()
+			digit := key == "1" || key == "2" || key == "3"
+			tab := key == "tab" || key == "shift+tab"
+			if digit || (tab && m.searchModel.dialogOpen()) {
+				break
+			}
+		}
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
@@ -136,7 +204,7 @@ func (m *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+a": // Explicitly handle Ctrl+A at the App level
 			// This case is added to ensure Ctrl+A does not accidentally trigger a global quit.
 			// The key message will then be passed down to the active sub-model's Update method.
-			log.Printf("AppModel: Caught Ctrl+A globally. Delegating to sub-model.")
+			m.logger.Debug("Caught Ctrl+A globally, delegating to sub-model")
 			// DO NOT return here. Let the message flow to the delegation logic below.
 		}
 
@@ -160,6 +228,53 @@ func (m *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		composeCmd := m.composeModel.SetSelectedFiles(m.currentTaggedFiles)
 		browseCmd := m.browseModel.SetTaggedFiles(m.currentTaggedFiles) // Update BrowseModel to reflect the untag
 		return m, tea.Batch(composeCmd, browseCmd)
+
+	case UntagGroupMsg: // Like UntagFileMsg, for a whole group at once.
+		m.searchModel.UntagGroup(msg.Group)
+		m.currentTaggedFiles = m.searchModel.GetTaggedFiles()
+		composeCmd := m.composeModel.SetSelectedFiles(m.currentTaggedFiles)
+		browseCmd := m.browseModel.SetTaggedFiles(m.currentTaggedFiles)
+		return m, tea.Batch(composeCmd, browseCmd)
+
+	case FileRangesMsg: // Like UntagFileMsg, for lines tagged or untagged in the Browse preview.
+		m.searchModel.SetFileRanges(msg.Path, msg.Ranges)
+		m.currentTaggedFiles = m.searchModel.GetTaggedFiles()
+		composeCmd := m.composeModel.SetSelectedFiles(m.currentTaggedFiles)
+		browseCmd := m.browseModel.SetTaggedFiles(m.currentTaggedFiles)
+		return m, tea.Batch(composeCmd, browseCmd)
+
+	case FileNoteMsg: // Like UntagFileMsg, for a note written on a file in Browse.
+		m.searchModel.SetFileNote(msg.Path, msg.Note)
+		m.currentTaggedFiles = m.searchModel.GetTaggedFiles()
+		composeCmd := m.composeModel.SetSelectedFiles(m.currentTaggedFiles)
+		browseCmd := m.browseModel.SetTaggedFiles(m.currentTaggedFiles)
+		return m, tea.Batch(composeCmd, browseCmd)
+
+	case MoveFileMsg: // Like UntagFileMsg, for a file moved up or down in Browse.
+		m.searchModel.MoveTaggedFile(msg.Path, msg.Delta)
+		m.currentTaggedFiles = m.searchModel.GetTaggedFiles()
+		composeCmd := m.composeModel.SetSelectedFiles(m.currentTaggedFiles)
+		browseCmd := m.browseModel.SetTaggedFiles(m.currentTaggedFiles)
+		return m, tea.Batch(composeCmd, browseCmd)
+
+	case FileOrderMsg: // Like UntagFileMsg, for the files reordered in Browse.
+		m.searchModel.SetFileOrder(msg.Order)
+		m.currentTaggedFiles = m.searchModel.GetTaggedFiles()
+		composeCmd := m.composeModel.SetSelectedFiles(m.currentTaggedFiles)
+		browseCmd := m.browseModel.SetTaggedFiles(m.currentTaggedFiles)
+		return m, tea.Batch(composeCmd, browseCmd)
+
+	case IndexReadyMsg, IndexChangedMsg, IndexErrorMsg, ScopeFilesMsg, previewContentMsg,
+		MsgDebouncedSearch, FuzzySearchResultsMsg, FuzzySearchErrorMsg, ContentSearchResultsMsg,
+		SymbolSearchResultsMsg, SearchResultsMsg, SearchErrorMsg, fileContentMsg, fileContentErrorMsg:
+		// Results of SearchModel's background work (the index, scopes, searches,
+		// previews and file loads) belong to it whichever tab is active. Dropping
+		// one would leave it waiting forever, e.g. a file stuck loading or the
+		// index no longer watched.
+		var searchModel tea.Model
+		searchModel, cmd = m.searchModel.Update(msg)
+		m.searchModel = searchModel.(*SearchModel)
+		return m, cmd
 	}
 
 	// If the message was not handled by the App model,
@@ -205,7 +320,7 @@ func (m *App) View() string {
 
 	// Render the global help text.
 	// Updated to reflect Ctrl+Q as quit key
-	help := styles.HelpStyle.Render("1,2,3: Jump to tab • Tab/Shift+Tab: Navigate • Ctrl+Q/Ctrl+C: Quit")
+	help := styles.HelpStyle.Render(m.helpText())
 
 	// Join all elements vertically to form the complete application layout.
 	main := lipgloss.JoinVertical(
@@ -226,6 +341,46 @@ func (m *App) View() string {
 		Render(main)
 }
 
+// typing reports whether the active tab takes digits as text (see
+// SearchModel.Typing and BrowseModel.Typing), so 1, 2 and 3 don't switch tabs.
+func (m *App) typing() bool {
+	switch m.state {
+	case SearchState:
+		return m.searchModel.Typing()
+	case BrowseState:
+		return m.browseModel.Typing()
+	}
+	return false
+}
+
+// helpText returns the global help line, leaving out the keys an input of the
+// active tab keeps for itself (see the tea.KeyMsg case of Update).
+func (m *App) helpText() string {
+	switch {
+	case m.state == BrowseState && m.browseModel.Typing():
+		return "Ctrl+C: Quit" // Every other key goes to the input
+	case m.state == SearchState && m.searchModel.dialogOpen():
+		return "Ctrl+Q/Ctrl+C: Quit" // Digits and Tab go to the dialog
+	case m.typing():
+		return "Tab/Shift+Tab: Navigate • Ctrl+Q/Ctrl+C: Quit" // Digits go to the query
+	}
+	return "1,2,3: Jump to tab • Tab/Shift+Tab: Navigate • Ctrl+Q/Ctrl+C: Quit"
+}
+
+// contentSize returns the room a tab's content gets inside the frame drawn by
+// View: the terminal size minus the border and padding of BaseStyle, and minus
+// the header, tab bar, spacers and help line around the content.
+func (m *App) contentSize() tea.WindowSizeMsg {
+	chrome := lipgloss.Height(styles.HeaderStyle.Render("🔍 Prompt Generator")) +
+		lipgloss.Height(m.renderTabs()) +
+		3 + // Spacers
+		1 // Help line
+	return tea.WindowSizeMsg{
+		Width:  max(m.width-8, 0),         // Border (2) and padding (4) of BaseStyle, plus the 2 columns View leaves
+		Height: max(m.height-6-chrome, 0), // Border (2) and padding (2) of BaseStyle, plus the 2 rows View leaves
+	}
+}
+
 // renderTabs generates the styled tab bar for navigation between states.
 func (m *App) renderTabs() string {
 	var tabs []string
@@ -273,8 +428,11 @@ func (m *App) renderTabs() string {
 	// Join all individual tabs horizontally.
 	tabBar := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 
-	// Add a hint for keyboard shortcuts to jump to tabs.
+	// Add a hint for keyboard shortcuts to jump to tabs, unless digits are being typed.
 	shortcutHint := styles.HelpStyle.Render("   1,2,3: Jump to tab")
+	if m.typing() {
+		shortcutHint = ""
+	}
 	tabBarWithHint := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		tabBar,
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"prompty/internal/logging"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestApp returns an App for a temporary project holding files (path ->
+// contents). History and frecency data go to a temporary directory as well.
+func newTestApp(t *testing.T, files map[string]string) *App {
+	t.Helper()
+	for _, env := range []string{"XDG_DATA_HOME", "XDG_STATE_HOME", "XDG_CONFIG_HOME"} {
+		t.Setenv(env, t.TempDir())
+	}
+	root := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := DefaultOptions()
+	opts.Dir = root
+	app := NewApp(opts)
+	t.Cleanup(func() { app.Close() })
+	return app
+}
+
+// send delivers msg to app as the Bubble Tea runtime would.
+func send(app *App, msg tea.Msg) tea.Cmd {
+	_, cmd := app.Update(msg)
+	return cmd
+}
+
+func TestIndexReadyOnOtherTab(t *testing.T) {
+	app := newTestApp(t, map[string]string{"main.go": "package main\n"})
+	ready := buildIndexCmd(app.searchModel.index, logging.Discard())()
+
+	// The index usually finishes building while the user is still on Search,
+	// but nothing stops them from switching tabs first.
+	send(app, tea.KeyMsg{Type: tea.KeyShiftTab})
+	if app.state != ComposeState {
+		t.Fatalf("state = %v after Shift+Tab, want Compose", app.state)
+	}
+	send(app, ready)
+	if !app.searchModel.indexReady {
+		t.Error("IndexReadyMsg delivered on the Compose tab did not reach the search model")
+	}
+}
+
+func TestLoadFinishesOnOtherTab(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"a.go": "package a\n",
+		"b.go": "package b\n",
+	})
+	send(app, buildIndexCmd(app.searchModel.index, logging.Discard())())
+
+	// Tag both files on the Search tab; their contents load in the background.
+	loadA, _ := app.searchModel.tagFile(FileItem{Path: "a.go"})
+	loadB, _ := app.searchModel.tagFile(FileItem{Path: "b.go"})
+	if err := os.Remove(filepath.Join(app.searchModel.baseDir, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	msgA, msgB := loadA(), loadB()
+	if _, ok := msgB.(fileContentErrorMsg); !ok {
+		t.Fatalf("loading a deleted file returned %T, want fileContentErrorMsg", msgB)
+	}
+
+	// The loads finish after the user moved on to Browse.
+	send(app, tea.KeyMsg{Type: tea.KeyTab})
+	if app.state != BrowseState {
+		t.Fatalf("state = %v after Tab, want Browse", app.state)
+	}
+	for _, msg := range []tea.Msg{msgA, msgB} {
+		if cmd := send(app, msg); cmd != nil {
+			send(app, cmd()) // The refreshed tagged files
+		}
+	}
+
+	if len(app.searchModel.loading) != 0 {
+		t.Errorf("still loading %v after every load finished", app.searchModel.loading)
+	}
+	files := app.browseModel.files
+	if len(files) != 2 || files[0].Path != "a.go" || files[0].Content != "package a\n" {
+		t.Errorf("Browse shows %+v, want a.go with its content and b.go", files)
+	}
+}
+
+func TestDigitsInSearchQuery(t *testing.T) {
+	app := newTestApp(t, nil)
+	key := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// With an empty query, digits switch tabs.
+	send(app, key("2"))
+	if app.state != BrowseState {
+		t.Fatalf("state = %v after pressing 2 with an empty query, want Browse", app.state)
+	}
+	send(app, key("1"))
+	if app.state != SearchState {
+		t.Fatalf("state = %v after pressing 1 on Browse, want Search", app.state)
+	}
+	if !strings.Contains(app.View(), "1,2,3: Jump to tab") {
+		t.Error("the tab switch keys are missing from the help with an empty query")
+	}
+
+	// Once a query is started, they are part of it.
+	for _, s := range []string{"s", "i", "z", "e", ":", "<", "2", "0", "k"} {
+		send(app, key(s))
+	}
+	if app.state != SearchState {
+		t.Fatalf("state = %v after typing a query, want Search", app.state)
+	}
+	if got := app.searchModel.textInput.Value(); got != "size:<20k" {
+		t.Errorf("query = %q, want size:<20k", got)
+	}
+	if strings.Contains(app.View(), "1,2,3") {
+		t.Error("the help offers digits to switch tabs while they go into the query")
+	}
+
+	// Tab still leaves the tab.
+	send(app, tea.KeyMsg{Type: tea.KeyTab})
+	if app.state != BrowseState {
+		t.Errorf("state = %v after Tab with a query, want Browse", app.state)
+	}
+}
@@ -2,8 +2,12 @@ package models
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"prompty/internal/content"
+	"prompty/internal/logging"
+	"prompty/internal/order"
 	"prompty/internal/search"
+	"prompty/internal/symbols"
 	"prompty/internal/ui/styles"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,19 +20,47 @@ type FileItem struct {
 	Path    string // The relative path of the file
 	Content string // The full content of the file (loaded lazily in SearchModel)
 	Tagged  bool   // Whether the file has been tagged by the user
+	// Omitted explains why Content was left out, e.g. "binary file, 2.1 MB".
+	// It is empty for text files, whose content is used as is.
+	Omitted string
+	// Untracked marks a file git doesn't track yet; it is shown with a "new" badge.
+	Untracked bool
+	// Group is the directory or glob the file was tagged through (see groupPrompt),
+	// or empty if it was tagged on its own. A group can be untagged all at once.
+	Group string
 	// OriginalMatch is an optional field to store the RipgrepMatch that led to this file,
 	// useful for context but not directly used in prompt composition.
 	// We keep it here for completeness, though it's mainly populated in SearchModel.
 	OriginalMatch *search.RipgrepMatch
+	// Ranges limits the file to these lines in the prompt (sorted and merged, see
+	// content.MergeRanges). It is empty when the whole file is tagged.
+	Ranges []content.LineRange
+	// Note is the user's note on a tagged file, e.g. "reference only, do not
+	// change". It goes into the prompt under the file's heading (see notes.go).
+	Note string
+	// Symbol is set for symbol search results: the declaration the row stands for.
+	// Tagging such a row adds Symbol.Lines to the file's Ranges.
+	Symbol *symbols.Symbol
+	// MatchedIndexes holds the rune offsets in Path hit by the current fuzzy query.
+	// It is only used to highlight search results.
+	MatchedIndexes []int
+	// Rank is the result's position in the matcher's ranking for the current query
+	// (0 is the best match). SearchModel uses it to restore score order after re-sorting.
+	Rank int
 }
 
 // BrowseModel handles the display and management of *already tagged* files.
 // It allows reviewing these files and untagging them if needed.
 type BrowseModel struct {
-	files       []FileItem // List of files currently displayed (these are already tagged)
-	cursor      int        // Index of the currently highlighted file
-	preview     string     // Content of the file currently being previewed
-	showPreview bool       // Flag to indicate if the file preview is active
+	files       []FileItem     // List of files currently displayed (these are already tagged)
+	cursor      int            // Index of the currently highlighted file
+	viewer      fileViewer     // Scrollable, searchable preview of the highlighted file; see viewer.go
+	note        notePrompt     // Input for the note on the highlighted file; see notes.go
+	showPreview bool           // Flag to indicate if the file preview is active
+	order       order.Strategy // How the files are ordered in the prompt, as last asked for; see ordering.go
+	width       int            // Width the tab gets, from the last WindowSizeMsg; 0 until one arrives
+	height      int            // Height the tab gets, from the last WindowSizeMsg
+	logger      *slog.Logger   // Structured logger
 }
 
 // Init initializes the browse model.
@@ -38,89 +70,185 @@ func (m *BrowseModel) Init() tea.Cmd {
 
 // NewBrowseModel creates a new browse model.
 // It starts with an an empty list of files, as files are passed from the App model.
-func NewBrowseModel() *BrowseModel {
+func NewBrowseModel(logger *slog.Logger) *BrowseModel {
 	return &BrowseModel{
 		files:       []FileItem{}, // Files will be set externally
 		cursor:      0,
-		preview:     "",
+		viewer:      newFileViewer(),
+		note:        newNotePrompt(),
 		showPreview: false,
+		logger:      logger,
 	}
 }
 
 // SetTaggedFiles updates the BrowseModel's file list with the currently tagged files.
 // This function is called by the App model when tagged files change in SearchModel.
 func (m *BrowseModel) SetTaggedFiles(files []FileItem) tea.Cmd {
-	log.Printf("BrowseModel: SetTaggedFiles received %d files.", len(files))
+	m.logger.Debug("Tagged files updated", "files", len(files))
+	var current string // Keep the cursor on the same file if it moved, e.g. after reordering
+	if m.cursor >= 0 && m.cursor < len(m.files) {
+		current = m.files[m.cursor].Path
+	}
 	m.files = files // Replace the current list with the new tagged files
+	for i, file := range m.files {
+		if file.Path == current {
+			m.cursor = i
+			break
+		}
+	}
 	// Reset cursor if the list is now empty or cursor is out of bounds
 	if len(m.files) == 0 {
 		m.cursor = 0
 	} else if m.cursor >= len(m.files) {
 		m.cursor = len(m.files) - 1
 	}
-	// If preview was active, clear it as the file list might have changed
+	// If preview was active, keep showing its file (where it was scrolled to) if
+	// it is still tagged, e.g. after its content finished loading; close it otherwise.
 	if m.showPreview {
 		m.showPreview = false
-		m.preview = ""
+		for i, file := range m.files {
+			if file.Path == m.viewer.path {
+				m.cursor = i
+				m.showPreview = true
+				m.viewer.open(file)
+				break
+			}
+		}
+		if !m.showPreview {
+			m.viewer.close()
+		}
 	}
 	return nil // No command returned
 }
 
+// Typing reports whether the note input or the preview's search or go-to-line
+// input is open, so App passes digits and other keys through instead of
+// switching tabs.
+func (m *BrowseModel) Typing() bool {
+	return m.note.active || (m.showPreview && m.viewer.typing())
+}
+
+// openPreview shows the highlighted file in the preview.
+func (m *BrowseModel) openPreview() {
+	m.showPreview = true
+	m.viewer.open(m.files[m.cursor])
+	m.resize()
+}
+
+// closePreview hides the preview and forgets where it was scrolled to.
+func (m *BrowseModel) closePreview() {
+	m.showPreview = false
+	m.viewer.close()
+}
+
+// listWidth returns how wide the list of tagged files is drawn: the full width,
+// or two fifths of it next to the preview.
+func (m *BrowseModel) listWidth() int {
+	if !m.showPreview {
+		return m.width
+	}
+	return m.width * 2 / 5
+}
+
+// resize sizes the preview and the note input from the room the tab gets. The
+// preview's title, position line, border and input line take five rows; the
+// gap before it and its border take four columns.
+func (m *BrowseModel) resize() {
+	if m.width == 0 {
+		return // No WindowSizeMsg yet; the viewer keeps its initial size
+	}
+	m.note.input.Width = max(m.listWidth()-lipgloss.Width(m.note.input.Prompt)-1, 1) // Prompt and cursor
+	m.viewer.setSize(m.width-m.listWidth()-4, m.height-5)
+	m.logger.Debug("Resized preview", "width", m.viewer.viewport.Width, "height", m.viewer.viewport.Height)
+}
+
 // Update handles messages for the BrowseModel.
 // It processes keyboard input for navigation, untagging, and previewing files.
 func (m *BrowseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd                                         // To batch commands
-	log.Printf("BrowseModel Update received message: %T", msg) // Log all incoming messages
+	var cmds []tea.Cmd                                                   // To batch commands
+	m.logger.Debug("Update", logging.KeyMsgType, fmt.Sprintf("%T", msg)) // Log all incoming messages
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// App sends the room the tab gets inside its frame, not the terminal size.
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+
+	case tea.MouseMsg: // The mouse wheel scrolls the preview
+		if m.showPreview {
+			var cmd tea.Cmd
+			m.viewer.viewport, cmd = m.viewer.viewport.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+
 	case tea.KeyMsg:
-		log.Printf("BrowseModel: KeyMsg received: %s (Type: %d, Mod: %d)", msg.String(), msg.Type)
+		if m.note.active {
+			// The note input gets every key until Enter or Esc.
+			return m, m.updateNotePrompt(msg)
+		}
+		if m.Typing() {
+			// The search or go-to-line input gets every key until Enter or Esc.
+			return m, m.viewer.update(msg)
+		}
+		// Logged only here: what is typed into the inputs above is the user's text.
+		m.logger.Debug("Key pressed", "key", msg.String())
+		switch msg.String() { // Ordering (see ordering.go) and notes (see notes.go)
+		case "a":
+			return m, m.openNotePrompt()
+		case "K", "alt+up":
+			return m, m.moveFile(-1)
+		case "J", "alt+down":
+			return m, m.moveFile(1)
+		case "o":
+			return m, m.cycleOrder()
+		}
 		switch msg.Type {
 		case tea.KeyCtrlN: // Only Ctrl+N for navigating down
-			log.Printf("BrowseModel: Ctrl+N pressed (down).")
 			if len(m.files) > 0 {
 				m.cursor = (m.cursor + 1) % len(m.files)
-				log.Printf("BrowseModel: Cursor moved to %d.", m.cursor)
+				m.logger.Debug("Cursor moved", "cursor", m.cursor)
 				// If preview is active, update it to the content of the newly selected file.
 				if m.showPreview {
-					m.preview = m.files[m.cursor].Content // Content should already be loaded
-					log.Printf("BrowseModel: Preview updated for %s.", m.files[m.cursor].Path)
+					m.viewer.open(m.files[m.cursor])
+					m.logger.Debug("Preview updated", logging.KeyPath, m.files[m.cursor].Path)
 				}
 			}
 		case tea.KeyCtrlP: // Only Ctrl+P for navigating up
-			log.Printf("BrowseModel: Ctrl+P pressed (up).")
 			if len(m.files) > 0 {
 				m.cursor = (m.cursor - 1 + len(m.files)) % len(m.files)
-				log.Printf("BrowseModel: Cursor moved to %d.", m.cursor)
+				m.logger.Debug("Cursor moved", "cursor", m.cursor)
 				// If preview is active, update it to the content of the newly selected file.
 				if m.showPreview {
-					m.preview = m.files[m.cursor].Content // Content should already be loaded
-					log.Printf("BrowseModel: Preview updated for %s.", m.files[m.cursor].Path)
+					m.viewer.open(m.files[m.cursor])
+					m.logger.Debug("Preview updated", logging.KeyPath, m.files[m.cursor].Path)
 				}
 			}
 		case tea.KeyEnter:
-			log.Printf("BrowseModel: Enter key pressed.")
+			if m.showPreview && m.viewer.selecting {
+				// Enter tags the lines selected in the preview; see lines.go.
+				return m, m.viewer.tagSelection()
+			}
 			// Toggle preview.
 			if m.cursor >= 0 && m.cursor < len(m.files) {
 				if m.showPreview {
-					m.showPreview = false
-					m.preview = ""
-					log.Printf("BrowseModel: Preview closed.")
+					m.closePreview()
+					m.logger.Debug("Preview closed")
 				} else {
-					m.showPreview = true
 					// Display content, which should already be loaded.
-					m.preview = m.files[m.cursor].Content
-					log.Printf("BrowseModel: Preview opened for %s.", m.files[m.cursor].Path)
+					m.openPreview()
+					m.logger.Debug("Preview opened", logging.KeyPath, m.files[m.cursor].Path)
 				}
 			}
 		case tea.KeyEsc:
-			log.Printf("BrowseModel: Esc key pressed.")
+			if m.showPreview && m.viewer.selecting {
+				// The first Esc only drops the selection of lines.
+				m.viewer.cancelSelection()
+				break
+			}
 			// Close preview.
-			m.showPreview = false
-			m.preview = ""
-			log.Printf("BrowseModel: Preview closed via Esc.")
+			m.closePreview()
+			m.logger.Debug("Preview closed via Esc")
 		case tea.KeyCtrlA: // Ctrl+A for untagging
-			log.Printf("BrowseModel: Ctrl+A pressed (untag).")
 			if m.cursor >= 0 && m.cursor < len(m.files) {
 				if m.files[m.cursor].Tagged { // Only untag if it's currently tagged
 					// Send a message to the App model so it can update the source of truth (SearchModel)
@@ -128,16 +256,27 @@ func (m *BrowseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					cmds = append(cmds, func() tea.Msg {
 						return UntagFileMsg{Path: m.files[m.cursor].Path}
 					})
-					log.Printf("BrowseModel: Sent UntagFileMsg for %s. Awaiting update from AppModel.", m.files[m.cursor].Path)
+					m.logger.Debug("Requested untag, awaiting update from App", logging.KeyPath, m.files[m.cursor].Path)
 
 					// DO NOT locally modify m.files here. The App model will re-set m.files
 					// via SetTaggedFiles with the correct, updated list.
 					// We can, however, clear the preview immediately for better UX.
-					m.showPreview = false
-					m.preview = ""
+					m.closePreview()
 					return m, tea.Batch(cmds...)
 				}
 			}
+		case tea.KeyCtrlX: // Ctrl+X untags the selected file's whole group
+			if m.cursor >= 0 && m.cursor < len(m.files) && m.files[m.cursor].Group != "" {
+				group := m.files[m.cursor].Group
+				m.logger.Debug("Requested group untag, awaiting update from App", "group", group)
+				m.closePreview()
+				return m, func() tea.Msg { return UntagGroupMsg{Group: group} }
+			}
+		default:
+			// Other keys scroll, search or jump within the preview.
+			if m.showPreview {
+				cmds = append(cmds, m.viewer.update(msg))
+			}
 		}
 	}
 
@@ -166,21 +305,53 @@ func (m *BrowseModel) View() string {
 
 			// All files here are conceptually tagged, so always show a checkmark
 			line := cursor + "✓ " + file.Path
-			fileList = append(fileList, style.Render(line))
+			if file.OriginalMatch != nil {
+				// Tagged from a content search hit; show where the match was.
+				line += fmt.Sprintf(" (line %d)", file.OriginalMatch.Line)
+			}
+			if len(file.Ranges) > 0 {
+				line += fmt.Sprintf(" (%s)", content.FormatRanges(file.Ranges))
+			}
+			if file.Omitted != "" {
+				line += fmt.Sprintf(" [%s]", file.Omitted)
+			}
+			line = style.Render(line)
+			if file.Untracked {
+				line += " " + styles.NewBadgeStyle.Render("new")
+			}
+			if file.Group != "" {
+				line += " " + lipgloss.NewStyle().Foreground(styles.MutedColor).Render("⊂ "+file.Group)
+			}
+			fileList = append(fileList, line)
+			if file.Note != "" {
+				fileList = append(fileList, noteLine(file.Note))
+			}
 		}
 	}
 
 	// Main content
 	title := lipgloss.NewStyle().Bold(true).Render(
 		fmt.Sprintf("📋 Tagged Files (%d)", taggedCount),
-	)
+	) + lipgloss.NewStyle().Foreground(styles.MutedColor).Render(fmt.Sprintf("  order: %s", m.order))
 
 	files := lipgloss.JoinVertical(lipgloss.Left, fileList...)
 
-	// Updated help text for new keybindings
-	help := styles.HelpStyle.Render(
-		"Ctrl+N/Ctrl+P: Navigate • Ctrl+A: Untag • Enter: Preview • Esc: Close preview",
+	// Updated help text for new keybindings; wrapped to the list's width next to the preview.
+	helpStyle := styles.HelpStyle
+	if m.width > 0 {
+		helpStyle = helpStyle.Width(m.listWidth())
+	}
+	help := helpStyle.Render(
+		"Ctrl+N/Ctrl+P: Navigate • Shift+K/Shift+J: Move up/down • o: Order by path/directory/configs/dependencies • a: Note • Ctrl+A: Untag • Ctrl+X: Untag group • Enter: Preview • v: Select lines to tag • Esc: Close preview",
 	)
+	if m.note.active {
+		// The note input takes the help's place until Enter or Esc.
+		help = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.note.input.View(),
+			styles.HelpStyle.Render("Enter: Save note (empty removes it) • Esc: Cancel"),
+		)
+	}
 
 	leftPanel := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -192,31 +363,16 @@ func (m *BrowseModel) View() string {
 	)
 
 	// If preview is shown, create two-column layout
-	if m.showPreview && m.preview != "" {
-		previewTitle := lipgloss.NewStyle().Bold(true).Render(
-			fmt.Sprintf("👁 Preview: %s", m.files[m.cursor].Path),
-		)
-
-		previewContent := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(styles.MutedColor).
-			Padding(1).
-			Width(60).
-			Height(15).
-			Render(m.preview)
-
-		rightPanel := lipgloss.JoinVertical(
-			lipgloss.Left,
-			previewTitle,
-			"",
-			previewContent,
-		)
-
+	if m.showPreview && m.cursor < len(m.files) {
+		if m.width > 0 {
+			// Long paths are cut rather than pushing the preview aside.
+			leftPanel = lipgloss.NewStyle().MaxWidth(m.listWidth()).Render(leftPanel)
+		}
 		return lipgloss.JoinHorizontal(
 			lipgloss.Top,
 			leftPanel,
 			"  ",
-			rightPanel,
+			m.viewer.view(),
 		)
 	}
 
@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"prompty/internal/content"
+	"prompty/internal/logging"
 	"prompty/internal/ui/styles"
 	"strings"
 
@@ -20,6 +22,7 @@ type ComposeModel struct {
 	finalPrompt   string
 	showOutput    bool
 	viewport      viewport.Model // Viewport for scrollable output
+	logger        *slog.Logger   // Structured logger; never given the prompt text itself
 }
 
 // Init initializes the compose model
@@ -27,8 +30,8 @@ func (m *ComposeModel) Init() tea.Cmd {
 	return textarea.Blink
 }
 
-// NewComposeModel creates a new compose model
-func NewComposeModel() *ComposeModel {
+// NewComposeModel creates a new compose model that logs to logger
+func NewComposeModel(logger *slog.Logger) *ComposeModel {
 	ta := textarea.New()
 	ta.Placeholder = "Enter your prompt here...\n\nExample: 'Please review this code and suggest improvements'"
 	ta.Focus()
@@ -47,19 +50,20 @@ func NewComposeModel() *ComposeModel {
 		finalPrompt:   "",
 		showOutput:    false,
 		viewport:      vp, // Initialize the viewport
+		logger:        logger,
 	}
 }
 
 // SetSelectedFiles updates the ComposeModel's list of files that will be included
 // in the generated prompt. This method is called by the App model.
 func (m *ComposeModel) SetSelectedFiles(files []FileItem) tea.Cmd {
-	log.Printf("ComposeModel: SetSelectedFiles received %d files.", len(files))
+	m.logger.Debug("Selected files updated", "files", len(files))
 	m.selectedFiles = files // Update the list of selected files
 	// If the output screen is currently visible, regenerate the prompt to reflect
 	// any changes in the selected files' content or list.
 	if m.showOutput {
 		m.generatePrompt()
-		log.Printf("ComposeModel: Regenerating prompt because output was shown.")
+		m.logger.Debug("Regenerated prompt because output was shown")
 	}
 	return nil // No command returned
 }
@@ -68,11 +72,11 @@ func (m *ComposeModel) SetSelectedFiles(files []FileItem) tea.Cmd {
 func (m *ComposeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd // To batch commands
-	log.Printf("ComposeModel Update received message: %T", msg)
+	m.logger.Debug("Update", logging.KeyMsgType, fmt.Sprintf("%T", msg))
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		log.Printf("ComposeModel: WindowSizeMsg received. Width: %d, Height: %d", msg.Width, msg.Height)
+		m.logger.Debug("Window resized", "width", msg.Width, "height", msg.Height)
 		// Calculate available dimensions for content area (adjust for borders/padding of BaseStyle and internal UI)
 		// Assuming BaseStyle takes up 2 units on each side (border + padding) and other UI elements
 		contentWidth := msg.Width - 4 // For overall BaseStyle padding/borders
@@ -94,12 +98,12 @@ func (m *ComposeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textarea.SetWidth(contentWidth)
 			// Textarea height is a fixed proportion or minimum
 			m.textarea.SetHeight(availableContentHeight / 2) // Example: half of available content height
-			log.Printf("ComposeModel: Resized textarea to W:%d H:%d", m.textarea.Width(), m.textarea.Height())
+			m.logger.Debug("Resized textarea", "width", m.textarea.Width(), "height", m.textarea.Height())
 		} else {
 			// When in output mode, adjust viewport size
 			m.viewport.Width = contentWidth
 			m.viewport.Height = availableContentHeight
-			log.Printf("ComposeModel: Resized viewport to W:%d H:%d", m.viewport.Width, m.viewport.Height)
+			m.logger.Debug("Resized viewport", "width", m.viewport.Width, "height", m.viewport.Height)
 		}
 		// Also update textarea and viewport with the WindowSizeMsg so they can re-render internally
 		m.textarea, cmd = m.textarea.Update(msg)
@@ -109,36 +113,31 @@ func (m *ComposeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case tea.KeyMsg:
-		log.Printf("ComposeModel: KeyMsg received: %s (Type: %d)", msg.String(), msg.Type)
 		switch msg.String() {
 		case "ctrl+g":
-			log.Printf("ComposeModel: Ctrl+G pressed (generate).")
 			// Generate final prompt
 			m.generatePrompt()
 			m.showOutput = true
-			log.Printf("ComposeModel: Prompt generated, showing output.")
+			m.logger.Info("Prompt generated, showing output")
 			return m, nil
 		case "esc":
-			log.Printf("ComposeModel: Esc key pressed.")
 			if m.showOutput {
 				m.showOutput = false
-				log.Printf("ComposeModel: Hiding output, returning to editing.")
+				m.logger.Debug("Hiding output, returning to editing")
 				return m, nil
 			}
 		case "y": // Copy to clipboard
-			log.Printf("ComposeModel: Y key pressed (copy).")
 			if m.showOutput {
 				err := clipboard.WriteAll(m.finalPrompt)
 				if err != nil {
-					log.Printf("ComposeModel: Error copying to clipboard: %v", err)
+					m.logger.Warn("Copying to clipboard failed", logging.KeyError, err)
 				} else {
-					log.Printf("ComposeModel: Prompt copied to clipboard successfully.")
+					m.logger.Info("Prompt copied to clipboard", "bytes", len(m.finalPrompt))
 				}
 				return m, nil
 			}
 		}
 		// Removed: case tea.MouseMsg: // Handle mouse events for viewport
-		// Removed: 	log.Printf("ComposeModel: MouseMsg received: %s", msg.String())
 		// Removed: 	if m.showOutput { // Only process mouse events for viewport if output is shown
 		// Removed: 		m.viewport, cmd = m.viewport.Update(msg)
 		// Removed: 		cmds = append(cmds, cmd)
@@ -162,7 +161,7 @@ func (m *ComposeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // generatePrompt creates the final prompt with selected files
 func (m *ComposeModel) generatePrompt() {
 	userPrompt := strings.TrimSpace(m.textarea.Value())
-	log.Printf("ComposeModel: generatePrompt called. User prompt length: %d", len(userPrompt))
+	m.logger.Debug("Generating prompt", "request_bytes", len(userPrompt))
 
 	var builder strings.Builder
 
@@ -176,23 +175,44 @@ func (m *ComposeModel) generatePrompt() {
 	// Add selected files
 	if len(m.selectedFiles) > 0 {
 		builder.WriteString("## Relevant Files\n\n")
-		log.Printf("ComposeModel: Adding %d selected files to prompt.", len(m.selectedFiles))
 
 		for _, file := range m.selectedFiles {
-			builder.WriteString(fmt.Sprintf("### %s\n\n", file.Path))
+			if len(file.Ranges) > 0 {
+				builder.WriteString(fmt.Sprintf("### %s (%s)\n\n", file.Path, content.FormatRanges(file.Ranges)))
+			} else {
+				builder.WriteString(fmt.Sprintf("### %s\n\n", file.Path))
+			}
+			if file.Note != "" {
+				// The user's note on the file goes right under its heading.
+				builder.WriteString(fmt.Sprintf("> Note: %s\n\n", file.Note))
+			}
+			if file.Omitted != "" {
+				// Binary or oversized: mention the file without pasting its bytes.
+				builder.WriteString(fmt.Sprintf("_Content omitted (%s)._\n\n", file.Omitted))
+				m.logger.Debug("Added placeholder to prompt", logging.KeyPath, file.Path, "reason", file.Omitted)
+				continue
+			}
+			if len(file.Ranges) > 0 {
+				// Only the tagged lines, numbered, with markers where lines were left out.
+				builder.WriteString("```\n")
+				builder.WriteString(content.Excerpt(file.Content, file.Ranges))
+				builder.WriteString("```\n\n")
+				m.logger.Debug("Added file ranges to prompt", logging.KeyPath, file.Path, "ranges", len(file.Ranges))
+				continue
+			}
 			builder.WriteString("```\n")
 			builder.WriteString(file.Content) // Use actual file content
 			builder.WriteString("```\n\n")
-			log.Printf("ComposeModel: Added file '%s' content (length: %d) to prompt.", file.Path, len(file.Content))
+			m.logger.Debug("Added file to prompt", logging.KeyPath, file.Path, "bytes", len(file.Content))
 		}
 	} else {
-		log.Printf("ComposeModel: No selected files to add.")
+		m.logger.Debug("No selected files to add")
 	}
 
 	m.finalPrompt = builder.String()
 	// Set the generated prompt content to the viewport
 	m.viewport.SetContent(m.finalPrompt)
-	log.Printf("ComposeModel: Final prompt generated. Total length: %d. Viewport content set.", len(m.finalPrompt))
+	m.logger.Debug("Prompt generated", "files", len(m.selectedFiles), "bytes", len(m.finalPrompt))
 }
 
 // View renders the compose interface
@@ -211,7 +231,14 @@ func (m *ComposeModel) View() string {
 		filesList = append(filesList, styles.HelpStyle.Render("No files selected yet. Go to 'Search' tab to find and tag files."))
 	} else {
 		for _, file := range m.selectedFiles {
-			filesList = append(filesList, "  ✓ "+file.Path)
+			line := "  ✓ " + file.Path
+			if len(file.Ranges) > 0 {
+				line += fmt.Sprintf(" (%s)", content.FormatRanges(file.Ranges))
+			}
+			filesList = append(filesList, line)
+			if file.Note != "" {
+				filesList = append(filesList, noteLine(file.Note))
+			}
 		}
 	}
 
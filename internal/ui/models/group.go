@@ -0,0 +1,250 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"prompty/internal/content"
+	"prompty/internal/glob"
+	"prompty/internal/ui/styles"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// groupPreviewFiles is how many of the matched paths the group prompt lists.
+const groupPreviewFiles = 5
+
+// groupPrompt is the Ctrl+O dialog of the Search tab, which tags every indexed
+// file under a directory or matching a glob at once. Typing shows how many files
+// the pattern matches; Enter asks for confirmation, and a second Enter (or y)
+// tags them. The files are tagged as a group named after the pattern, so they
+// can be untagged together later (Ctrl+X in the Browse tab).
+type groupPrompt struct {
+	input      textinput.Model // The directory or glob being typed
+	active     bool            // Whether the dialog is open
+	confirming bool            // Whether the expansion below is waiting for a yes/no
+	pattern    string          // Normalised pattern the expansion belongs to; also the group name
+	paths      []string        // Indexed files the pattern matches
+	size       int64           // Total size of paths, in bytes
+	tagged     int             // How many of paths are tagged already
+	err        error           // Why the pattern can't be used, if it can't
+}
+
+// newGroupPrompt creates the (closed) group tagging dialog.
+func newGroupPrompt() groupPrompt {
+	ti := textinput.New()
+	ti.Prompt = "Tag directory or glob: "
+	ti.Placeholder = "internal/ui/ or internal/**/*.go"
+	ti.Width = 60 // Without a width, only the first character of the placeholder shows
+	return groupPrompt{input: ti}
+}
+
+// openGroupPrompt opens the group dialog, pre-filled with the directory of the
+// highlighted result so tagging "this package" is Ctrl+O, Enter, Enter.
+func (m *SearchModel) openGroupPrompt() tea.Cmd {
+	value := ""
+	if m.cursor >= 0 && m.cursor < len(m.results) {
+		if dir := path.Dir(m.results[m.cursor].Path); dir != "." {
+			value = dir + "/"
+		}
+	}
+	m.group.active = true
+	m.group.confirming = false
+	m.group.input.SetValue(value)
+	m.group.input.CursorEnd()
+	m.expandGroup()
+	m.logger.Debug("Opened group prompt", "pattern", value)
+	return m.group.input.Focus()
+}
+
+// closeGroupPrompt closes the group dialog without tagging anything.
+func (m *SearchModel) closeGroupPrompt() {
+	m.group.active = false
+	m.group.confirming = false
+	m.group.input.Blur()
+}
+
+// updateGroupPrompt handles a key press while the group dialog is open.
+func (m *SearchModel) updateGroupPrompt(msg tea.KeyMsg) tea.Cmd {
+	if m.group.confirming {
+		switch msg.String() {
+		case "enter", "y", "Y":
+			cmd := m.tagGroup()
+			m.closeGroupPrompt()
+			return cmd
+		case "esc", "n", "N":
+			m.group.confirming = false // Back to editing the pattern
+		}
+		return nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeGroupPrompt()
+		return nil
+	case tea.KeyEnter:
+		if m.group.err == nil && len(m.group.paths) > m.group.tagged {
+			m.group.confirming = true
+		}
+		return nil
+	}
+
+	before := m.group.input.Value()
+	var cmd tea.Cmd
+	m.group.input, cmd = m.group.input.Update(msg)
+	if m.group.input.Value() != before {
+		m.expandGroup()
+	}
+	return cmd
+}
+
+// expandGroup lists the indexed files matched by the pattern in the dialog.
+// A pattern without glob characters names a directory (or a single file); a
+// glob is matched against whole paths, so use internal/** for everything below
+// internal/. The index already leaves out ignored files, so they never match.
+func (m *SearchModel) expandGroup() {
+	g := &m.group
+	g.paths, g.size, g.tagged, g.err = nil, 0, 0, nil
+
+	pattern := strings.TrimPrefix(strings.TrimSpace(g.input.Value()), "./")
+	g.pattern = pattern
+	if pattern == "" {
+		return
+	}
+
+	var match func(string) bool
+	if glob.HasMeta(pattern) {
+		re, err := glob.Compile(pattern)
+		if err != nil {
+			g.err = err
+			return
+		}
+		match = re.MatchString
+	} else {
+		dir := strings.TrimSuffix(pattern, "/")
+		if dir == "." || dir == "" {
+			g.pattern = "./"
+			match = func(string) bool { return true }
+		} else {
+			g.pattern = dir + "/"
+			match = func(p string) bool { return p == dir || strings.HasPrefix(p, dir+"/") }
+		}
+	}
+
+	for _, p := range m.index.Paths() {
+		if !match(p) {
+			continue
+		}
+		g.paths = append(g.paths, p)
+		if entry, ok := m.index.Lookup(p); ok {
+			g.size += entry.Size
+		}
+		if m.isTagged(p) {
+			g.tagged++
+		}
+	}
+	if len(g.paths) == 1 && g.paths[0] == strings.TrimSuffix(pattern, "/") {
+		g.pattern = g.paths[0] // A single file rather than a directory
+	}
+}
+
+// tagGroup tags every file of the confirmed expansion that isn't tagged yet,
+// recording the pattern as their group.
+func (m *SearchModel) tagGroup() tea.Cmd {
+	return m.tagAsGroup(m.group.paths, m.group.pattern)
+}
+
+// tagAsGroup tags each of paths that isn't tagged yet as part of group.
+func (m *SearchModel) tagAsGroup(paths []string, group string) tea.Cmd {
+	var cmds []tea.Cmd
+	added := 0
+	for _, p := range paths {
+		cmd, ok := m.tagFile(FileItem{Path: p, Group: group})
+		if ok {
+			cmds = append(cmds, cmd)
+			added++
+		}
+	}
+	m.logger.Info("Tagged group", "group", group, "tagged", added, "matched", len(paths))
+
+	// Reflect the new tags in the visible results.
+	for i := range m.results {
+		if !m.results[i].Tagged && m.results[i].Symbol == nil && m.isTagged(m.results[i].Path) {
+			m.results[i].Tagged = true
+		}
+	}
+	if m.textInput.Value() == "" {
+		m.showTaggedFiles()
+	}
+	cmds = append(cmds, func() tea.Msg { return TaggedFilesMsg(m.GetTaggedFiles()) })
+	return tea.Batch(cmds...)
+}
+
+// UntagGroup removes every file tagged as part of group from the persistent
+// allTaggedFiles list. Files of the group that were untagged one by one are
+// already gone; files tagged individually are never part of a group.
+func (m *SearchModel) UntagGroup(group string) {
+	kept := m.allTaggedFiles[:0]
+	removed := 0
+	for _, tagged := range m.allTaggedFiles {
+		if tagged.Group == group {
+			removed++
+			continue
+		}
+		kept = append(kept, tagged)
+	}
+	m.allTaggedFiles = kept
+	for i := range m.results {
+		if m.results[i].Tagged && !m.isTagged(m.results[i].Path) {
+			m.results[i].Tagged = false
+		}
+	}
+	if m.textInput.Value() == "" {
+		m.showTaggedFiles()
+	}
+	m.logger.Info("Untagged group", "group", group, "files", removed)
+}
+
+// groupPromptView renders the open group dialog.
+func (m *SearchModel) groupPromptView() string {
+	g := &m.group
+	muted := lipgloss.NewStyle().Foreground(styles.MutedColor)
+
+	var lines []string
+	lines = append(lines, g.input.View())
+	switch {
+	case g.err != nil:
+		lines = append(lines, lipgloss.NewStyle().Foreground(styles.ErrorColor).Render("Invalid pattern: "+g.err.Error()))
+	case g.pattern == "":
+		lines = append(lines, muted.Render("Type a directory (tags everything below it) or a glob such as internal/**/*.go."))
+	case len(g.paths) == 0:
+		lines = append(lines, muted.Render("No indexed files match."))
+	default:
+		summary := fmt.Sprintf("%d files, %s", len(g.paths), content.FormatSize(g.size))
+		if g.tagged > 0 {
+			summary += fmt.Sprintf(" (%d already tagged)", g.tagged)
+		}
+		lines = append(lines, muted.Render(summary))
+		for _, p := range g.paths[:min(len(g.paths), groupPreviewFiles)] {
+			lines = append(lines, muted.Render("  "+p))
+		}
+		if more := len(g.paths) - groupPreviewFiles; more > 0 {
+			lines = append(lines, muted.Render(fmt.Sprintf("  … and %d more", more)))
+		}
+	}
+
+	if g.confirming {
+		question := fmt.Sprintf("Tag %d files as group %q? Enter/y: Yes • Esc/n: No", len(g.paths)-g.tagged, g.pattern)
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(styles.AccentColor).Bold(true).Render(question))
+	} else {
+		lines = append(lines, "", styles.HelpStyle.Render("Enter: Review & confirm • Esc: Cancel"))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.PrimaryColor).
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,282 @@
+package models
+
+import (
+	"fmt"
+	"log/slog"
+	"prompty/internal/history"
+	"prompty/internal/logging"
+	"prompty/internal/search"
+	"prompty/internal/ui/styles"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// historyPickerRows is how many entries the history picker shows at once.
+const historyPickerRows = 8
+
+// historyPicker is the Ctrl+R overlay of the Search tab, which recalls an
+// earlier query. It lists the project's saved queries first, then the queries
+// searched before, most recent first, and narrows both down with the fuzzy
+// matcher as you type. Enter runs the highlighted query again in the mode it
+// was run in. Alt+S opens the same overlay as a prompt for saving the current
+// query under a name.
+type historyPicker struct {
+	input   textinput.Model     // Filter for the entries, or the name when saving
+	active  bool                // Whether the overlay is open
+	saving  bool                // Whether the input is the name for the current query
+	entries []history.Entry     // Saved queries, then recent ones
+	matches []search.FuzzyMatch // Entries accepted by the filter; Index points into entries
+	cursor  int                 // Highlighted match
+	query   string              // Query being saved, when saving
+	mode    SearchMode          // Mode of the query being saved, when saving
+	match   search.MatchMode    // Match mode of the query being saved, when saving
+}
+
+// newHistoryPicker creates the (closed) history picker.
+func newHistoryPicker() historyPicker {
+	ti := textinput.New()
+	ti.Width = 60 // Without a width, only the first character of the placeholder shows
+	return historyPicker{input: ti}
+}
+
+// parseSearchMode returns the search mode labelled s (see SearchMode.String),
+// falling back to file search for labels it doesn't know.
+func parseSearchMode(s string) SearchMode {
+	for mode := FileSearchMode; mode <= SymbolSearchMode; mode++ {
+		if mode.String() == s {
+			return mode
+		}
+	}
+	return FileSearchMode
+}
+
+// historyCandidate returns the text an entry is matched against and shown as:
+// the name and the query for saved queries, just the query otherwise.
+func historyCandidate(entry history.Entry) string {
+	if entry.Name != "" {
+		return entry.Name + "  " + entry.Query
+	}
+	return entry.Query
+}
+
+// historyModeLabel describes the modes a query runs in, e.g. "Files" or
+// "Symbols · regex"; fuzzy matching, the default, isn't mentioned.
+func historyModeLabel(mode, match string) string {
+	if match == "" || match == search.MatchFuzzy.String() || mode == ContentSearchMode.String() {
+		return mode
+	}
+	return mode + " · " + match
+}
+
+// saveHistoryCmd writes the history store to disk in the background.
+func saveHistoryCmd(store *history.Store, logger *slog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Save(); err != nil {
+			logger.Warn("Saving search history failed", logging.KeyError, err)
+		}
+		return nil
+	}
+}
+
+// rememberQuery records the current query in the project's search history and
+// returns the command saving it. Queries are recorded when they are searched
+// with Enter or lead to tagging, not on every debounced keystroke.
+func (m *SearchModel) rememberQuery() tea.Cmd {
+	query := strings.TrimSpace(m.textInput.Value())
+	if query == "" {
+		return nil
+	}
+	m.history.Add(m.baseDir, history.Entry{Query: query, Mode: m.mode.String(), Match: m.matchLabel(), Used: time.Now()})
+	return saveHistoryCmd(m.history, m.logger)
+}
+
+// openHistoryPicker opens the picker over the project's saved and recent queries.
+func (m *SearchModel) openHistoryPicker() tea.Cmd {
+	p := &m.picker
+	p.active, p.saving = true, false
+	p.entries = append(m.history.Saved(m.baseDir), m.history.Recent(m.baseDir)...)
+	p.input.Prompt = "History: "
+	p.input.Placeholder = "type to filter saved and recent queries"
+	p.input.SetValue("")
+	m.filterHistory()
+	m.logger.Debug("Opened history picker", "entries", len(p.entries))
+	return p.input.Focus()
+}
+
+// openSaveQueryPrompt opens the picker as a prompt for the name to save the
+// current query under. Saving under an existing name replaces that query.
+func (m *SearchModel) openSaveQueryPrompt() tea.Cmd {
+	query := strings.TrimSpace(m.textInput.Value())
+	if query == "" {
+		m.err = fmt.Errorf("type a query before saving it")
+		return nil
+	}
+	p := &m.picker
+	p.active, p.saving = true, true
+	p.query, p.mode, p.match = query, m.mode, m.match
+	p.entries, p.matches, p.cursor = nil, nil, 0
+	p.input.Prompt = "Save query as: "
+	p.input.Placeholder = "name"
+	p.input.SetValue("")
+	return p.input.Focus()
+}
+
+// closeHistoryPicker closes the picker without running or saving anything.
+func (m *SearchModel) closeHistoryPicker() {
+	m.picker.active = false
+	m.picker.saving = false
+	m.picker.input.Blur()
+}
+
+// filterHistory matches the picker's entries against its filter. An empty
+// filter keeps every entry in order.
+func (m *SearchModel) filterHistory() {
+	p := &m.picker
+	candidates := make([]string, len(p.entries))
+	for i, entry := range p.entries {
+		candidates[i] = historyCandidate(entry)
+	}
+	p.matches = search.FuzzyFind(p.input.Value(), candidates)
+	p.cursor = 0
+}
+
+// updateHistoryPicker handles a key press while the picker is open.
+func (m *SearchModel) updateHistoryPicker(msg tea.KeyMsg) tea.Cmd {
+	p := &m.picker
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeHistoryPicker()
+		return nil
+	case tea.KeyEnter:
+		if p.saving {
+			return m.saveQuery()
+		}
+		if p.cursor < len(p.matches) {
+			return m.runHistoryEntry(p.entries[p.matches[p.cursor].Index])
+		}
+		return nil
+	case tea.KeyCtrlN, tea.KeyDown:
+		if len(p.matches) > 0 {
+			p.cursor = (p.cursor + 1) % len(p.matches)
+		}
+		return nil
+	case tea.KeyCtrlP, tea.KeyUp:
+		if len(p.matches) > 0 {
+			p.cursor = (p.cursor - 1 + len(p.matches)) % len(p.matches)
+		}
+		return nil
+	case tea.KeyCtrlD: // Ctrl+D forgets the highlighted entry
+		if !p.saving && p.cursor < len(p.matches) {
+			return m.deleteHistoryEntry()
+		}
+		return nil
+	}
+
+	before := p.input.Value()
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	if !p.saving && p.input.Value() != before {
+		m.filterHistory()
+	}
+	return cmd
+}
+
+// runHistoryEntry closes the picker and searches entry's query again, in the
+// search and match modes it was run in.
+func (m *SearchModel) runHistoryEntry(entry history.Entry) tea.Cmd {
+	m.closeHistoryPicker()
+	m.mode = parseSearchMode(entry.Mode)
+	if m.mode != ContentSearchMode {
+		m.match = search.ParseMatchMode(entry.Match)
+	}
+	m.textInput.Placeholder = m.placeholder()
+	m.textInput.SetValue(entry.Query)
+	m.textInput.CursorEnd()
+	m.showTaggedFiles()
+	m.logger.Debug("Recalled query", "mode", m.mode.String(), logging.SavedQuery(entry.Name, entry.Query))
+	return tea.Batch(m.startSearch(entry.Query), m.rememberQuery())
+}
+
+// saveQuery saves the query the prompt was opened for under the typed name.
+func (m *SearchModel) saveQuery() tea.Cmd {
+	p := &m.picker
+	name := strings.TrimSpace(p.input.Value())
+	if name == "" {
+		return nil
+	}
+	entry := history.Entry{Name: name, Query: p.query, Mode: p.mode.String(), Used: time.Now()}
+	if p.mode != ContentSearchMode && p.match != search.MatchFuzzy {
+		entry.Match = p.match.String()
+	}
+	m.history.SaveQuery(m.baseDir, entry)
+	m.logger.Info("Saved query", "mode", p.mode.String(), logging.SavedQuery(name, p.query))
+	m.closeHistoryPicker()
+	return saveHistoryCmd(m.history, m.logger)
+}
+
+// deleteHistoryEntry forgets the highlighted entry: a saved query, or one of
+// the recent ones.
+func (m *SearchModel) deleteHistoryEntry() tea.Cmd {
+	p := &m.picker
+	i := p.matches[p.cursor].Index
+	m.history.Delete(m.baseDir, p.entries[i])
+	m.logger.Debug("Deleted history entry", logging.SavedQuery(p.entries[i].Name, p.entries[i].Query))
+	p.entries = append(p.entries[:i], p.entries[i+1:]...)
+	cursor := p.cursor
+	m.filterHistory()
+	p.cursor = min(cursor, max(len(p.matches)-1, 0))
+	return saveHistoryCmd(m.history, m.logger)
+}
+
+// historyPickerView renders the open picker, or the save prompt.
+func (m *SearchModel) historyPickerView() string {
+	p := &m.picker
+	muted := lipgloss.NewStyle().Foreground(styles.MutedColor)
+
+	lines := []string{p.input.View()}
+	switch {
+	case p.saving:
+		lines = append(lines,
+			muted.Render(fmt.Sprintf("%s search: %s", historyModeLabel(p.mode.String(), p.match.String()), p.query)),
+			"",
+			styles.HelpStyle.Render("Enter: Save • Esc: Cancel"))
+	case len(p.entries) == 0:
+		lines = append(lines, muted.Render("No queries yet: searches run with Enter, or that tag files, are remembered here. Alt+S saves a query by name."))
+	case len(p.matches) == 0:
+		lines = append(lines, muted.Render("No queries match."))
+	default:
+		// Scroll the window of visible rows with the cursor.
+		start := max(0, min(p.cursor-historyPickerRows/2, len(p.matches)-historyPickerRows))
+		for i := start; i < min(start+historyPickerRows, len(p.matches)); i++ {
+			match := p.matches[i]
+			entry := p.entries[match.Index]
+			style, marker := styles.NormalStyle, "  "
+			if i == p.cursor {
+				style, marker = styles.SelectedStyle, "▶ "
+			}
+			badge := "  "
+			if entry.Name != "" {
+				badge = "★ "
+			}
+			lines = append(lines, style.Render(marker+badge)+
+				renderHighlighted(historyCandidate(entry), match.MatchedIndexes, style)+
+				muted.Render("  "+historyModeLabel(entry.Mode, entry.Match)))
+		}
+		if len(p.matches) > historyPickerRows {
+			lines = append(lines, muted.Render(fmt.Sprintf("  %d of %d queries", min(p.cursor+1, len(p.matches)), len(p.matches))))
+		}
+	}
+	if !p.saving {
+		lines = append(lines, "", styles.HelpStyle.Render("Enter: Run • Ctrl+N/Ctrl+P: Navigate • Ctrl+D: Delete • Esc: Close"))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.PrimaryColor).
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
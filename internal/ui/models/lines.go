@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"prompty/internal/content"
+	"prompty/internal/ui/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Tagging line ranges in the Browse preview. "v" starts a selection at the
+// line jumped to last (or the top of the screen), the scroll keys extend it,
+// and "v" or Enter again adds the selected lines to the file's tagged ranges,
+// so only those lines (with their numbers) go into the prompt. For a file
+// tagged whole the selection becomes its only range. "x" takes the selected
+// lines, or the tagged range under the cursor, back out.
+
+// FileRangesMsg is sent from BrowseModel to App when lines of a tagged file
+// were tagged or untagged in the preview. Like UntagFileMsg it goes through
+// App to SearchModel, which holds the tagged files.
+type FileRangesMsg struct {
+	Path   string
+	Ranges []content.LineRange // The file's new ranges, merged; nil tags the whole file
+}
+
+// taggedLineMark marks the lines of tagged ranges in the preview's gutter.
+var taggedLineMark = lipgloss.NewStyle().Foreground(styles.SecondaryColor).Bold(true).Render("┃")
+
+// selection returns the selected lines, in order.
+func (v *fileViewer) selection() content.LineRange {
+	return content.LineRange{Start: min(v.anchor, v.focus), End: max(v.anchor, v.focus)}
+}
+
+// startSelection starts selecting lines at the line jumped to last, if it is
+// on screen, or else at the first line on screen.
+func (v *fileViewer) startSelection() {
+	v.notice, v.err = "", nil
+	if v.omitted != "" || len(v.lines) == 0 {
+		v.err = fmt.Errorf("this file has no lines to tag")
+		return
+	}
+	top := v.viewport.YOffset + 1
+	if v.focus < top || v.focus >= top+v.viewport.Height {
+		v.focus = min(top, len(v.lines))
+	}
+	v.selecting, v.anchor = true, v.focus
+	v.render()
+}
+
+// cancelSelection drops the selection (Esc).
+func (v *fileViewer) cancelSelection() {
+	v.selecting = false
+	v.render()
+}
+
+// moveSelection moves the selection's end for the scroll keys, keeping it on
+// screen. It reports whether key was one of them.
+func (v *fileViewer) moveSelection(key string) bool {
+	line := v.focus
+	switch key {
+	case "down", "j":
+		line++
+	case "up", "k":
+		line--
+	case "ctrl+d", "d":
+		line += v.viewport.Height / 2
+	case "ctrl+u", "u":
+		line -= v.viewport.Height / 2
+	case "pgdown", "f", " ":
+		line += v.viewport.Height
+	case "pgup", "b":
+		line -= v.viewport.Height
+	case "g", "home":
+		line = 1
+	case "G", "end":
+		line = len(v.lines)
+	default:
+		return false
+	}
+	v.focus = max(min(line, len(v.lines)), 1)
+	if top := v.viewport.YOffset + 1; v.focus < top {
+		v.viewport.SetYOffset(v.focus - 1)
+	} else if v.focus >= top+v.viewport.Height {
+		v.viewport.SetYOffset(v.focus - v.viewport.Height)
+	}
+	v.render()
+	return true
+}
+
+// tagSelection adds the selected lines to the file's tagged ranges and ends
+// the selection. The new ranges reach SearchModel through App.
+func (v *fileViewer) tagSelection() tea.Cmd {
+	sel := v.selection()
+	v.selecting = false
+	var ranges []content.LineRange
+	if len(v.ranges) == 0 {
+		// The whole file was tagged; now only the selection is.
+		ranges = []content.LineRange{sel}
+		v.notice = fmt.Sprintf("the prompt now has only %s of this file", content.FormatRanges(ranges))
+	} else {
+		ranges = content.MergeRanges(append(v.ranges, sel))
+		v.notice = fmt.Sprintf("tagged %s", content.FormatRanges([]content.LineRange{sel}))
+	}
+	if content.Covers(ranges, content.LineRange{Start: 1, End: len(v.lines)}) {
+		ranges = nil // Every line is tagged; that's the whole file
+		v.notice = "the whole file goes into the prompt again"
+	}
+	v.render()
+	return v.setRanges(ranges)
+}
+
+// untagLines takes the selected lines, or without a selection the tagged range
+// under the cursor, out of the file's tagged ranges. It refuses to take out
+// every line; Ctrl+A untags the file.
+func (v *fileViewer) untagLines() tea.Cmd {
+	v.notice, v.err = "", nil
+	if v.omitted != "" || len(v.lines) == 0 {
+		v.err = fmt.Errorf("this file has no lines to untag")
+		return nil
+	}
+	current := v.ranges
+	if len(current) == 0 {
+		current = []content.LineRange{{Start: 1, End: len(v.lines)}}
+	}
+
+	var remove content.LineRange
+	switch {
+	case v.selecting:
+		remove = v.selection()
+	case v.focus > 0:
+		for _, r := range v.ranges {
+			if r.Contains(content.LineRange{Start: v.focus, End: v.focus}) {
+				remove = r
+			}
+		}
+	}
+	if remove.Start == 0 {
+		v.err = fmt.Errorf("select the lines to untag with v first")
+		return nil
+	}
+
+	ranges := content.RemoveRange(current, remove)
+	if len(ranges) == 0 {
+		v.err = fmt.Errorf("that would leave no lines of this file; press Ctrl+A to untag it")
+		return nil
+	}
+	v.selecting = false
+	v.notice = fmt.Sprintf("untagged %s", content.FormatRanges([]content.LineRange{remove}))
+	v.render()
+	return v.setRanges(ranges)
+}
+
+// setRanges returns the command telling App about the file's new ranges. The
+// viewer shows them once App hands the updated file back.
+func (v *fileViewer) setRanges(ranges []content.LineRange) tea.Cmd {
+	path := v.path
+	return func() tea.Msg {
+		return FileRangesMsg{Path: path, Ranges: ranges}
+	}
+}
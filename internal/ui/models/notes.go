@@ -0,0 +1,106 @@
+package models
+
+import (
+	"prompty/internal/logging"
+	"prompty/internal/ui/styles"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Notes on tagged files. In the Browse tab, "a" opens a one-line input for a
+// note on the highlighted file, e.g. "this is the buggy handler" or "reference
+// only, do not change". Enter saves it and an empty note removes it. The note
+// is kept with the tagged file in SearchModel and goes into the prompt right
+// under the file's heading, so per-file guidance stays with its file instead of
+// being repeated in the free-text request.
+
+// maxNoteLen is the most characters a note can have; it is meant to be a hint,
+// not a second prompt.
+const maxNoteLen = 200
+
+// FileNoteMsg is sent from BrowseModel to App when the note on a tagged file
+// was written, changed or cleared. Like UntagFileMsg it goes through App to
+// SearchModel, which holds the tagged files.
+type FileNoteMsg struct {
+	Path string
+	Note string // The file's new note; empty removes it
+}
+
+// noteStyle renders notes in the lists of tagged files.
+var noteStyle = lipgloss.NewStyle().Foreground(styles.MutedColor).Italic(true)
+
+// notePrompt is the Browse tab's input for the note on a tagged file.
+type notePrompt struct {
+	input  textinput.Model // The note being typed
+	active bool            // Whether the input is open
+	path   string          // The file the note is for
+}
+
+// newNotePrompt creates the (closed) note input.
+func newNotePrompt() notePrompt {
+	ti := textinput.New()
+	ti.Prompt = "Note: "
+	ti.Placeholder = "e.g. this is the buggy handler"
+	ti.CharLimit = maxNoteLen
+	ti.Width = 60 // Until the first WindowSizeMsg; see BrowseModel.resize
+	return notePrompt{input: ti}
+}
+
+// noteLine renders a file's note as its own line under the file in a list.
+func noteLine(note string) string {
+	return noteStyle.Render("    📝 " + note)
+}
+
+// SetFileNote sets the note on the tagged file at path; an empty note removes it.
+func (m *SearchModel) SetFileNote(path, note string) {
+	for i := range m.allTaggedFiles {
+		if m.allTaggedFiles[i].Path == path {
+			m.allTaggedFiles[i].Note = note
+			m.logger.Info("Set file note", logging.KeyPath, path, "chars", len(note))
+			return
+		}
+	}
+	m.logger.Warn("Note for a file that is not tagged", logging.KeyPath, path)
+}
+
+// openNotePrompt opens the note input for the highlighted file, pre-filled with
+// its current note so it can be edited.
+func (m *BrowseModel) openNotePrompt() tea.Cmd {
+	if m.cursor < 0 || m.cursor >= len(m.files) {
+		return nil
+	}
+	file := m.files[m.cursor]
+	m.note.active = true
+	m.note.path = file.Path
+	m.note.input.SetValue(file.Note)
+	m.note.input.CursorEnd()
+	m.logger.Debug("Opened note prompt", logging.KeyPath, file.Path)
+	return m.note.input.Focus()
+}
+
+// closeNotePrompt closes the note input, keeping the note as it was.
+func (m *BrowseModel) closeNotePrompt() {
+	m.note.active = false
+	m.note.input.Blur()
+}
+
+// updateNotePrompt handles a key press while the note input is open: Enter
+// saves the note, Esc leaves it as it was.
+func (m *BrowseModel) updateNotePrompt(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeNotePrompt()
+		return nil
+	case tea.KeyEnter:
+		path, note := m.note.path, strings.TrimSpace(m.note.input.Value())
+		m.closeNotePrompt()
+		m.logger.Debug("Requested note, awaiting update from App", logging.KeyPath, path)
+		return func() tea.Msg { return FileNoteMsg{Path: path, Note: note} }
+	}
+	var cmd tea.Cmd
+	m.note.input, cmd = m.note.input.Update(msg)
+	return cmd
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"prompty/internal/logging"
+	"prompty/internal/order"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The order of the tagged files, which is the order they appear in the prompt.
+// In the Browse tab, Shift+K/Shift+J (or Alt+Up/Alt+Down) move the highlighted
+// file up or down, and "o" cycles the automatic orderings: by path, by
+// directory, configs first and dependency order, and back to manual, which
+// keeps the current order and adds new files at the end. The ordering is kept
+// with the tagged files in SearchModel, so files tagged later are slotted in by
+// the same rule; moving a file by hand switches back to the manual order.
+
+// MoveFileMsg is sent from BrowseModel to App to move a tagged file up or
+// down in the prompt. Like UntagFileMsg it goes through App to SearchModel.
+type MoveFileMsg struct {
+	Path  string
+	Delta int // -1 moves the file up one place, 1 down one place
+}
+
+// FileOrderMsg is sent from BrowseModel to App to reorder the tagged files.
+type FileOrderMsg struct {
+	Order order.Strategy
+}
+
+// FileOrder returns how the tagged files are ordered.
+func (m *SearchModel) FileOrder() order.Strategy {
+	return m.fileOrder
+}
+
+// SetFileOrder reorders the tagged files by strategy s and keeps them in that
+// order as files are tagged.
+func (m *SearchModel) SetFileOrder(s order.Strategy) {
+	m.fileOrder = s
+	m.logger.Info("File order changed", "order", s.String())
+	m.applyFileOrder()
+}
+
+// MoveTaggedFile moves the tagged file at path delta places up (negative) or
+// down, within bounds, and switches to the manual order so it stays there.
+func (m *SearchModel) MoveTaggedFile(path string, delta int) {
+	for i := range m.allTaggedFiles {
+		if m.allTaggedFiles[i].Path != path {
+			continue
+		}
+		j := max(min(i+delta, len(m.allTaggedFiles)-1), 0)
+		if i == j {
+			return
+		}
+		file := m.allTaggedFiles[i]
+		if j < i {
+			copy(m.allTaggedFiles[j+1:i+1], m.allTaggedFiles[j:i])
+		} else {
+			copy(m.allTaggedFiles[i:j], m.allTaggedFiles[i+1:j+1])
+		}
+		m.allTaggedFiles[j] = file
+		m.fileOrder = order.Manual
+		m.logger.Debug("Moved tagged file", logging.KeyPath, path, "from", i, "to", j)
+		return
+	}
+}
+
+// applyFileOrder sorts the tagged files by the active strategy. Dependency
+// order needs the files' contents, so while any are loading it waits for the
+// last load to finish (see finishLoad), which also keeps bulk tagging from
+// re-sorting once per file.
+func (m *SearchModel) applyFileOrder() {
+	if m.fileOrder == order.Manual || len(m.loading) > 0 || len(m.allTaggedFiles) < 2 {
+		return
+	}
+	files := make([]order.File, len(m.allTaggedFiles))
+	for i, f := range m.allTaggedFiles {
+		files[i] = order.File{Path: f.Path, Content: f.Content}
+	}
+	sorted := make([]FileItem, 0, len(m.allTaggedFiles))
+	for _, i := range order.Sort(m.fileOrder, files) {
+		sorted = append(sorted, m.allTaggedFiles[i])
+	}
+	m.allTaggedFiles = sorted
+}
+
+// moveFile asks App to move the highlighted file delta places; the cursor
+// follows it once the reordered files come back (see SetTaggedFiles).
+func (m *BrowseModel) moveFile(delta int) tea.Cmd {
+	if m.cursor < 0 || m.cursor >= len(m.files) {
+		return nil
+	}
+	path := m.files[m.cursor].Path
+	m.order = order.Manual
+	return func() tea.Msg { return MoveFileMsg{Path: path, Delta: delta} }
+}
+
+// cycleOrder asks App to reorder the tagged files by the next strategy ("o").
+func (m *BrowseModel) cycleOrder() tea.Cmd {
+	m.order = m.order.Next()
+	s := m.order
+	m.logger.Debug("Requested file order", "order", s.String())
+	return func() tea.Msg { return FileOrderMsg{Order: s} }
+}
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"prompty/internal/content"
+	"prompty/internal/logging"
+	"prompty/internal/ui/styles"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// previewMinWidth is the narrowest Search tab that shows the preview pane;
+// below it the results get the whole width.
+const previewMinWidth = 100
+
+// previewTabWidth is how many spaces a tab is expanded to in previews.
+const previewTabWidth = 4
+
+// previewContentMsg carries the content of the file shown in the preview.
+type previewContentMsg struct {
+	Path    string // File the content belongs to
+	Text    string // The content; empty when Omitted or Err is set
+	Omitted string // Why the content isn't shown (binary or too large), if it isn't
+	Err     error  // Why the file couldn't be read, if it couldn't
+}
+
+// searchPreview is the pane next to the search results that shows the file of
+// the highlighted result. It jumps to, and highlights, the matched line of a
+// content hit and the lines of a symbol. Alt+P turns it on and off; Alt+J and
+// Alt+K scroll it by a line, Alt+Shift+J and Alt+Shift+K by half a page.
+type searchPreview struct {
+	enabled  bool               // Whether the pane is wanted; it also needs a wide enough terminal
+	viewport viewport.Model     // Scrollable, rendered content
+	path     string             // File shown (or being loaded)
+	text     string             // Content of path
+	omitted  string             // Why path's content isn't shown, if it isn't
+	err      error              // Why path couldn't be read, if it couldn't
+	loading  bool               // Whether path's content is still being read
+	focus    content.LineRange  // Lines jumped to and highlighted; zero for none
+	rendered string             // path and focus the viewport was last rendered for
+	cancel   context.CancelFunc // Cancels the read of a file no longer highlighted
+}
+
+// newSearchPreview creates the preview pane, enabled but not yet sized.
+func newSearchPreview() searchPreview {
+	vp := viewport.New(0, 0)
+	vp.MouseWheelEnabled = false // The wheel scrolls the results
+	return searchPreview{enabled: true, viewport: vp}
+}
+
+// loadPreviewCmd reads the file at relPath for the preview through the shared
+// content loader, so it counts against the same worker limit as tagged files.
+func (m *SearchModel) loadPreviewCmd(ctx context.Context, relPath string) tea.Cmd {
+	loader, abs, logger := m.loader, m.index.Abs(relPath), m.logger
+	return func() tea.Msg {
+		file, err := loader.Load(ctx, abs)
+		if ctx.Err() != nil {
+			return nil // Another result was highlighted meanwhile
+		}
+		if err != nil {
+			logger.Debug("Loading preview failed", logging.KeyPath, relPath, logging.KeyError, err)
+			return previewContentMsg{Path: relPath, Err: err}
+		}
+		return previewContentMsg{Path: relPath, Text: file.Text, Omitted: file.Placeholder()}
+	}
+}
+
+// previewVisible reports whether the preview pane is shown.
+func (m *SearchModel) previewVisible() bool {
+	return m.preview.enabled && m.width >= previewMinWidth
+}
+
+// togglePreview shows or hides the preview pane (Alt+P).
+func (m *SearchModel) togglePreview() {
+	m.preview.enabled = !m.preview.enabled
+	if m.preview.enabled && m.width > 0 && m.width < previewMinWidth {
+		m.err = fmt.Errorf("the preview needs a terminal at least %d columns wide", previewMinWidth)
+	}
+	m.logger.Debug("Preview toggled", "enabled", m.preview.enabled)
+	m.resize()
+}
+
+// previewFocus returns the lines the preview should jump to for item: the
+// matched line of a content hit or the declaration of a symbol.
+func previewFocus(item FileItem) content.LineRange {
+	switch {
+	case item.OriginalMatch != nil:
+		return content.LineRange{Start: item.OriginalMatch.Line, End: item.OriginalMatch.Line}
+	case item.Symbol != nil:
+		return item.Symbol.Lines
+	}
+	return content.LineRange{}
+}
+
+// syncPreview makes the preview follow the highlighted result. It starts
+// reading the result's file if another file is shown, and otherwise re-renders
+// the pane if the lines to focus on changed. It is run after every update.
+func (m *SearchModel) syncPreview() tea.Cmd {
+	p := &m.preview
+	if !m.previewVisible() {
+		return nil
+	}
+	if m.cursor < 0 || m.cursor >= len(m.results) {
+		if p.path != "" {
+			m.clearPreview()
+		}
+		return nil
+	}
+	item := m.results[m.cursor]
+	p.focus = previewFocus(item)
+	if item.Path == p.path {
+		if !p.loading {
+			m.renderPreview()
+		}
+		return nil
+	}
+
+	m.clearPreview()
+	ctx, cancel := context.WithCancel(m.loadCtx)
+	p.path, p.loading, p.cancel = item.Path, true, cancel
+	p.focus = previewFocus(item)
+	return m.loadPreviewCmd(ctx, item.Path)
+}
+
+// clearPreview empties the preview pane and cancels a read still under way.
+func (m *SearchModel) clearPreview() {
+	p := &m.preview
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.path, p.text, p.omitted, p.err, p.loading, p.cancel, p.rendered = "", "", "", nil, false, nil, ""
+	p.viewport.SetContent("")
+}
+
+// handlePreviewContent takes in the content read for the preview.
+func (m *SearchModel) handlePreviewContent(msg previewContentMsg) {
+	p := &m.preview
+	if msg.Path != p.path {
+		return // Stale: another file is highlighted now
+	}
+	p.text, p.omitted, p.err, p.loading = msg.Text, msg.Omitted, msg.Err, false
+	p.rendered = ""
+	m.renderPreview()
+}
+
+// renderPreview renders the previewed file into the viewport with line
+// numbers, highlights the focus lines and scrolls them into view. It does
+// nothing when the file and focus haven't changed since the last render, so
+// scrolling by hand isn't undone.
+func (m *SearchModel) renderPreview() {
+	p := &m.preview
+	key := p.path + "\x00" + p.focus.String()
+	if key == p.rendered {
+		return
+	}
+	p.rendered = key
+
+	switch {
+	case p.err != nil:
+		p.viewport.SetContent(lipgloss.NewStyle().Foreground(styles.ErrorColor).Render("Error: " + p.err.Error()))
+		return
+	case p.omitted != "":
+		p.viewport.SetContent(lipgloss.NewStyle().Foreground(styles.MutedColor).Render("[" + p.omitted + "]"))
+		return
+	}
+
+	lines := previewLines(p.text)
+	width := len(strconv.Itoa(len(lines)))
+	var b strings.Builder
+	for i, line := range lines {
+		n := i + 1
+		if p.focus.Start > 0 && n >= p.focus.Start && n <= p.focus.End {
+			b.WriteString(styles.FocusLineStyle.Render(fmt.Sprintf("%*d ▶ %s", width, n, line)))
+		} else {
+			b.WriteString(styles.LineNumberStyle.Render(fmt.Sprintf("%*d", width, n)) + "   " + line)
+		}
+		b.WriteString("\n")
+	}
+	p.viewport.SetContent(b.String())
+
+	if p.focus.Start > 0 {
+		// Show a few lines of context above the focus.
+		p.viewport.SetYOffset(max(p.focus.Start-1-p.viewport.Height/3, 0))
+	} else {
+		p.viewport.GotoTop()
+	}
+}
+
+// previewLines splits text into the lines a preview shows, with tabs expanded
+// and carriage returns dropped, so the viewport measures and cuts them right.
+func previewLines(text string) []string {
+	expand := strings.NewReplacer("\t", strings.Repeat(" ", previewTabWidth), "\r", "")
+	return strings.Split(expand.Replace(strings.TrimSuffix(text, "\n")), "\n")
+}
+
+// scrollPreview handles the preview's scroll keys. It reports whether key was one of them.
+func (m *SearchModel) scrollPreview(key string) bool {
+	if !m.previewVisible() {
+		return false
+	}
+	vp := &m.preview.viewport
+	switch key {
+	case "alt+j":
+		vp.ScrollDown(1)
+	case "alt+k":
+		vp.ScrollUp(1)
+	case "alt+J":
+		vp.HalfPageDown()
+	case "alt+K":
+		vp.HalfPageUp()
+	default:
+		return false
+	}
+	return true
+}
+
+// previewView renders the preview pane: a title naming the file (and line),
+// then the scrollable content.
+func (m *SearchModel) previewView() string {
+	p := &m.preview
+	muted := lipgloss.NewStyle().Foreground(styles.MutedColor)
+
+	title := "👁 Preview"
+	if p.path != "" {
+		title += ": " + p.path
+		if p.focus.Start > 0 {
+			title += ":" + p.focus.String()
+		}
+	}
+	var body string
+	switch {
+	case p.path == "":
+		body = muted.Render("Highlight a result to preview its file.")
+	case p.loading:
+		body = muted.Render("Loading...")
+	default:
+		body = p.viewport.View()
+	}
+
+	status := muted.Render("Alt+J/Alt+K: Scroll • Alt+P: Hide")
+	if p.path != "" && !p.loading && p.text != "" {
+		status = muted.Render(fmt.Sprintf("%3.f%% • ", p.viewport.ScrollPercent()*100)) + status
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(styles.MutedColor).
+		PaddingLeft(1).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			"",
+			lipgloss.NewStyle().Bold(true).MaxWidth(p.viewport.Width).Render(title),
+			status,
+			body,
+		))
+}
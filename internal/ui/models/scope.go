@@ -0,0 +1,151 @@
+package models
+
+import (
+	"fmt"
+	"log/slog"
+	"prompty/internal/index"
+	"prompty/internal/logging"
+	"prompty/internal/ui/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Git scopes in the Search tab. Ctrl+G cycles the file set searches draw from:
+// every indexed file, files modified in the working tree, staged files, files
+// changed on the branch against its base, and files touched by the last few
+// commits. File, content and symbol searches only consider files in the scope,
+// and with an empty query a file search lists all of them. Alt+T tags every
+// file in the scope as a group named after it.
+
+// ScopeFilesMsg carries the files of a git scope, listed in the background.
+type ScopeFilesMsg struct {
+	Scope index.Scope // Scope the files were listed for
+	Files []string    // Indexed files in the scope, in git's order
+	Base  string      // Base branch the branch scope compared against
+	Err   error       // Why git couldn't list the scope, if it couldn't
+	// Reload is set when the files were listed again because the index changed,
+	// rather than because the scope was picked.
+	Reload bool
+}
+
+// loadScopeCmd lists the files of scope with git.
+func loadScopeCmd(ix *index.Index, scope index.Scope, opts index.ScopeOptions, reload bool, logger *slog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		files, base, err := ix.ScopeFiles(scope, opts)
+		if err != nil {
+			logger.Warn("Listing scope files failed", "scope", scope.Label(opts), logging.KeyError, err)
+		}
+		return ScopeFilesMsg{Scope: scope, Files: files, Base: base, Err: err, Reload: reload}
+	}
+}
+
+// scopeLabel describes the active scope, e.g. "staged" or "main...HEAD".
+func (m *SearchModel) scopeLabel() string {
+	return m.scope.Label(m.scopeOpts)
+}
+
+// cycleScope switches to the next git scope and starts listing its files.
+func (m *SearchModel) cycleScope() tea.Cmd {
+	if !m.indexReady {
+		m.err = fmt.Errorf("the file index is still being built")
+		return nil
+	}
+	m.scope = m.scope.Next()
+	m.scopeFiles, m.scopeSet = nil, nil
+	m.err = nil
+	m.logger.Debug("Scope changed", "scope", m.scopeLabel())
+	if m.scope == index.ScopeAll {
+		return m.searchScope()
+	}
+	m.querying = true // Until the scope's files are listed
+	return loadScopeCmd(m.index, m.scope, m.scopeOpts, false, m.logger)
+}
+
+// reloadScope lists the files of the active scope again, e.g. after files
+// changed on disk. It returns nil when every file is in scope.
+func (m *SearchModel) reloadScope() tea.Cmd {
+	if m.scope == index.ScopeAll {
+		return nil
+	}
+	return loadScopeCmd(m.index, m.scope, m.scopeOpts, true, m.logger)
+}
+
+// handleScopeFiles takes in the listed files of a scope and searches them.
+// If git couldn't list them, the search falls back to every file. After a
+// reload, content searches aren't re-run, like for any other index change.
+func (m *SearchModel) handleScopeFiles(msg ScopeFilesMsg) tea.Cmd {
+	if msg.Scope != m.scope {
+		return nil // The scope was changed again while git was running
+	}
+	if msg.Err != nil {
+		m.scope = index.ScopeAll
+		m.scopeFiles, m.scopeSet = nil, nil
+		cmd := m.searchScope()
+		m.err = fmt.Errorf("git scope unavailable: %w", msg.Err)
+		return cmd
+	}
+	if msg.Base != "" {
+		m.scopeOpts.Base = msg.Base // Remember the detected base branch
+	}
+	m.scopeFiles = msg.Files
+	m.scopeSet = make(map[string]bool, len(msg.Files))
+	for _, p := range msg.Files {
+		m.scopeSet[p] = true
+	}
+	m.logger.Info("Scope loaded", "scope", m.scopeLabel(), "files", len(msg.Files), "reload", msg.Reload)
+	if msg.Reload && m.mode == ContentSearchMode {
+		return nil
+	}
+	return m.searchScope()
+}
+
+// searchScope refreshes the results after the scope changed: the query is
+// searched again, and an empty query lists the scope's files (or, for every
+// file, goes back to the tagged files).
+func (m *SearchModel) searchScope() tea.Cmd {
+	query := m.textInput.Value()
+	if query == "" && (m.scope == index.ScopeAll || m.mode != FileSearchMode) {
+		m.cancelActiveSearch()
+		m.showTaggedFiles()
+		return nil
+	}
+	return m.startSearch(query)
+}
+
+// scopeReady reports whether searches can run: either every file is in scope
+// or the scope's files have been listed.
+func (m *SearchModel) scopeReady() bool {
+	return m.scope == index.ScopeAll || m.scopeSet != nil
+}
+
+// tagScope tags every file in the active scope that isn't tagged yet, as a
+// group named after the scope (Alt+T).
+func (m *SearchModel) tagScope() tea.Cmd {
+	if m.scope == index.ScopeAll {
+		m.err = fmt.Errorf("pick a git scope with Ctrl+G first")
+		return nil
+	}
+	if !m.scopeReady() {
+		return nil // Still listing; try again in a moment
+	}
+	if len(m.scopeFiles) == 0 {
+		m.err = fmt.Errorf("no files in scope %s", m.scopeLabel())
+		return nil
+	}
+	return m.tagAsGroup(m.scopeFiles, m.scopeLabel())
+}
+
+// scopeBadge renders the active scope for the search title.
+func (m *SearchModel) scopeBadge() string {
+	muted := lipgloss.NewStyle().Foreground(styles.MutedColor)
+	if m.scope == index.ScopeAll {
+		return "  " + muted.Render("[all files] Ctrl+G")
+	}
+	label := m.scopeLabel()
+	if m.scopeSet != nil {
+		label += fmt.Sprintf(" · %d files", len(m.scopeFiles))
+	}
+	return "  " + lipgloss.NewStyle().Foreground(styles.SecondaryColor).Bold(true).Render("["+label+"]") +
+		muted.Render(" Ctrl+G • Alt+T: Tag all")
+}
@@ -1,17 +1,24 @@
 package models
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"prompty/internal/content"
+	"prompty/internal/frecency"
+	"prompty/internal/history"
+	"prompty/internal/index"
+	"prompty/internal/logging"
+	"prompty/internal/order"
+	"prompty/internal/search"
+	"prompty/internal/symbols"
 	"prompty/internal/ui/styles"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -25,13 +32,15 @@ type SearchResultsMsg []FileItem
 
 // SearchErrorMsg is a custom message type to convey errors from the search operation.
 type SearchErrorMsg struct {
+	Gen uint64 // Generation of the search that failed
 	Err error
 }
 
 // fileContentMsg is a custom message type for when a file's content has been successfully loaded.
 type fileContentMsg struct {
 	Path    string // Path of the file whose content was loaded
-	Content string // The loaded content
+	Content string // The loaded content; empty when Omitted is set
+	Omitted string // Why the content was left out (binary or too large), if it was
 }
 
 // fileContentErrorMsg is a custom message type for when an error occurs during file content loading.
@@ -40,40 +49,167 @@ type fileContentErrorMsg struct {
 	Err  error  // The error itself
 }
 
-// FuzzySearchResultsMsg is a custom message type for results returned by non-interactive fzf.
-type FuzzySearchResultsMsg []string // slice of fuzzy-matched file paths
+// ContentSearchResultsMsg carries the matches returned by a ripgrep content search.
+type ContentSearchResultsMsg struct {
+	Gen     uint64                // Generation of the search that produced these matches
+	Matches []search.RipgrepMatch // ripgrep hits, in ripgrep's output order
+}
+
+// IndexReadyMsg is sent once the file index has been built at startup.
+type IndexReadyMsg struct {
+	Files   int // Number of indexed files
+	Ignored int // Number of files left out by the ignore rules
+}
+
+// IndexChangedMsg is sent when the file index picked up changes on disk.
+type IndexChangedMsg struct{}
+
+// IndexErrorMsg is sent when the file index could not be built.
+type IndexErrorMsg struct {
+	Err error
+}
+
+// FuzzySearchResultsMsg is a custom message type for results returned by the fuzzy matcher.
+type FuzzySearchResultsMsg struct {
+	Gen     uint64              // Generation of the search that produced these matches
+	Matches []search.FuzzyMatch // Ranked fuzzy-matched file paths
+}
 
-// FuzzySearchErrorMsg is a custom message type for errors from non-interactive fzf.
+// FuzzySearchErrorMsg is a custom message type for errors from the fuzzy file search.
 type FuzzySearchErrorMsg struct {
+	Gen uint64 // Generation of the search that failed
 	Err error
 }
 
+// SymbolSearchResultsMsg carries the declarations found by a symbol search.
+type SymbolSearchResultsMsg struct {
+	Gen     uint64        // Generation of the search that produced these matches
+	Matches []SymbolMatch // Ranked matches, best first
+}
+
+// SymbolMatch is a declaration whose name matched a symbol search.
+type SymbolMatch struct {
+	Symbol         symbols.Symbol
+	MatchedIndexes []int // Rune offsets in Symbol.Name hit by the query
+}
+
+// SearchMode selects what the search query is matched against.
+type SearchMode int
+
+const (
+	FileSearchMode    SearchMode = iota // 0: Fuzzy match on file paths
+	ContentSearchMode                   // 1: Grep file contents (ripgrep)
+	SymbolSearchMode                    // 2: Fuzzy match on declaration names
+)
+
+// maxContentResults caps how many ripgrep hits (and symbol matches) are
+// shown, so a broad pattern doesn't flood the results list.
+const maxContentResults = 1000
+
+// String returns a short, human readable label for the search mode.
+func (mode SearchMode) String() string {
+	switch mode {
+	case ContentSearchMode:
+		return "Content"
+	case SymbolSearchMode:
+		return "Symbols"
+	default:
+		return "Files"
+	}
+}
+
+// next returns the search mode that follows mode when cycling with Ctrl+T.
+func (mode SearchMode) next() SearchMode {
+	return (mode + 1) % (SymbolSearchMode + 1)
+}
+
+// SortOrder selects how the (unpinned) search results are ordered.
+type SortOrder int
+
+const (
+	SortByScore   SortOrder = iota // 0: Matcher relevance, best first
+	SortByPath                     // 1: Alphabetically by path
+	SortByModTime                  // 2: Most recently modified first
+	SortBySize                     // 3: Largest first
+)
+
+// String returns a short, human readable label for the sort order.
+func (order SortOrder) String() string {
+	switch order {
+	case SortByPath:
+		return "path"
+	case SortByModTime:
+		return "modified"
+	case SortBySize:
+		return "size"
+	default:
+		return "score"
+	}
+}
+
+// next returns the sort order that follows order when cycling with Ctrl+S.
+func (order SortOrder) next() SortOrder {
+	return (order + 1) % (SortBySize + 1)
+}
+
 // SearchModel handles the search functionality, including the search input,
 // displaying results, and allowing navigation and tagging within those results.
 type SearchModel struct {
-	textInput       textinput.Model // Bubble Tea text input component for search query
-	results         []FileItem      // Stores the parsed results as FileItem, allowing tagging
-	cursor          int             // Index of the currently highlighted result
-	debounceTicker  *time.Ticker    // Ticker for debouncing search queries
-	lastUpdate      time.Time       // Timestamp of the last text input update
-	querying        bool            // Flag to indicate if a search is in progress (now for fzf execution)
-	err             error           // Stores any error that occurred during the search
-	baseDir         string          // The base directory for file paths
-	resultsViewport viewport.Model  // Added: Viewport for scrollable search results
-	allTaggedFiles  []FileItem      // New: Stores all persistently tagged files
+	textInput       textinput.Model    // Bubble Tea text input component for search query
+	results         []FileItem         // Stores the parsed results as FileItem, allowing tagging
+	pinned          int                // Number of leading results that make up the pinned "tagged" section
+	sortOrder       SortOrder          // How results after the pinned section are ordered
+	cursor          int                // Index of the currently highlighted result
+	debounceTicker  *time.Ticker       // Ticker for debouncing search queries
+	lastUpdate      time.Time          // Timestamp of the last text input update
+	querying        bool               // Flag to indicate if a search is in progress
+	searchGen       uint64             // Generation of the latest search; results from older ones are dropped
+	cancelSearch    context.CancelFunc // Cancels the in-flight search, if any
+	err             error              // Stores any error that occurred during the search
+	baseDir         string             // The base directory for file paths
+	resultsViewport viewport.Model     // Added: Viewport for scrollable search results
+	allTaggedFiles  []FileItem         // New: Stores all persistently tagged files
+	fileOrder       order.Strategy     // How allTaggedFiles, and so the prompt, is ordered; see ordering.go
+	mode            SearchMode         // Whether the query matches file names or file contents
+	match           search.MatchMode   // How the query text is matched against paths and symbol names (Alt+M); kept for the session
+	index           *index.Index       // In-memory index of every searchable path, relative to baseDir
+	scope           index.Scope        // Git scope searches are limited to (Ctrl+G); see scope.go
+	scopeOpts       index.ScopeOptions // Base branch and commit count for the scopes that need them
+	scopeFiles      []string           // Files in the active scope, in git's order; nil until listed
+	scopeSet        map[string]bool    // scopeFiles as a set; nil while every file is in scope or still listing
+	symbols         *symbols.Table     // Declarations of the indexed files, for symbol search
+	indexReady      bool               // Whether the index has finished its initial build
+	frecency        *frecency.Store    // How often and how recently each file was tagged, per project
+	history         *history.Store     // Queries searched and saved, per project, for the Ctrl+R picker
+	loader          *content.Loader    // Reads file contents for tagged files with a bounded number of workers
+	loadCtx         context.Context    // Parent context of content loads; cancelled by Close
+	cancelLoads     context.CancelFunc // Cancels loads still waiting for a worker
+	loading         map[string]bool    // Paths whose content is being loaded
+	loadFailed      map[string]bool    // Paths whose last load failed; their Content holds the error
+	loadDone        int                // Loads finished since loading last went idle, for the progress indicator
+	loadTotal       int                // Loads started since loading last went idle, for the progress indicator
+	pendingTags     []string           // Paths from --tag, tagged once the index is ready
+	group           groupPrompt        // Ctrl+O dialog for tagging a directory or glob at once
+	picker          historyPicker      // Ctrl+R picker over earlier queries; also the Alt+S save prompt
+	preview         searchPreview      // Pane showing the highlighted result's file (Alt+P); see preview.go
+	width           int                // Width the tab gets, from the last WindowSizeMsg; 0 until one arrives
+	height          int                // Height the tab gets, from the last WindowSizeMsg
+	selecting       bool               // Whether a range of results is being selected (Ctrl+X)
+	anchor          int                // Index where the range selection started; the cursor is its other end
+	logger          *slog.Logger       // Structured logger; queries are logged under logging.KeyQuery so they can be redacted
 }
 
 // Init initializes the search model.
-// It returns a command to make the text input blink its cursor.
+// It returns a command to make the text input blink its cursor and starts loading the file list.
 func (m *SearchModel) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, buildIndexCmd(m.index, m.logger))
 }
 
 // NewSearchModel creates and initializes a new SearchModel.
 // It sets up the text input, initializes debouncing, and gets the current working directory.
-func NewSearchModel() *SearchModel {
+func NewSearchModel(opts Options) *SearchModel {
 	ti := textinput.New()
-	ti.Placeholder = "Type to fuzzy search for files..."
+	ti.Placeholder = "Type to fuzzy search for files, e.g. 'model path:internal/ test:no'... (Ctrl+T: search contents)"
 	ti.Focus()
 	// Initial width, will be adjusted by WindowSizeMsg to full available width.
 	ti.Width = 200
@@ -84,11 +220,37 @@ func NewSearchModel() *SearchModel {
 	vp.HighPerformanceRendering = false // Can set to true for performance, but might redraw more often
 	vp.MouseWheelEnabled = true         // Enabled mouse wheel scrolling for search results
 
-	baseDir, err := os.Getwd()
+	if opts.Logger == nil {
+		opts.Logger = logging.Discard()
+	}
+	logger := opts.Logger.With(logging.KeyModel, "search")
+
+	baseDir := opts.Dir
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			logger.Error("Getting the current working directory failed", logging.KeyError, err)
+		}
+	}
+	if opts.Query != "" {
+		ti.SetValue(opts.Query) // Searched as soon as the index is ready
+	}
+
+	// Remember which files get tagged, so the ones used most often rank higher next time.
+	frecencyStore, err := frecency.Open(frecency.DefaultPath())
+	if err != nil {
+		// Not fatal: the store starts empty and is rewritten on the next save.
+		logger.Warn("Loading frecency data failed", logging.KeyError, err)
+	}
+	// Remember the queries searched, so they can be recalled with Ctrl+R.
+	historyStore, err := history.Open(history.DefaultPath())
 	if err != nil {
-		log.Printf("SearchModel: Error getting current working directory: %v", err)
+		logger.Warn("Loading search history failed", logging.KeyError, err)
 	}
 
+	loadCtx, cancelLoads := context.WithCancel(context.Background())
+
 	return &SearchModel{
 		textInput:       ti,
 		results:         []FileItem{},
@@ -100,9 +262,33 @@ func NewSearchModel() *SearchModel {
 		baseDir:         baseDir,
 		resultsViewport: vp,           // Initialize the results viewport
 		allTaggedFiles:  []FileItem{}, // Initialize the new persistent store
+		mode:            FileSearchMode,
+		sortOrder:       SortByScore,
+		index:           index.New(baseDir, opts.Logger.With(logging.KeyModel, "index")),
+		scopeOpts:       index.ScopeOptions{Base: opts.Base, Commits: opts.RecentCommits},
+		symbols:         symbols.NewTable(baseDir, opts.Logger.With(logging.KeyModel, "symbols")),
+		frecency:        frecencyStore,
+		history:         historyStore,
+		loader:          content.NewLoader(content.DefaultWorkers, opts.MaxFileSize),
+		loadCtx:         loadCtx,
+		cancelLoads:     cancelLoads,
+		loading:         make(map[string]bool),
+		loadFailed:      make(map[string]bool),
+		pendingTags:     opts.Tags,
+		group:           newGroupPrompt(),
+		picker:          newHistoryPicker(),
+		preview:         newSearchPreview(),
+		logger:          logger,
 	}
 }
 
+// Close releases resources held by the search model, such as the index's filesystem watcher.
+func (m *SearchModel) Close() error {
+	m.cancelActiveSearch()
+	m.cancelLoads()
+	return m.index.Close()
+}
+
 // MsgDebouncedSearch is a custom message sent when the debounce timer finishes.
 type MsgDebouncedSearch struct{}
 
@@ -114,145 +300,499 @@ func debounceCmd(interval time.Duration) tea.Cmd {
 	})
 }
 
-// getFileListCommand prepares the command to generate the file list.
-func getFileListCommand(baseDir string) *exec.Cmd {
-	if _, err := exec.LookPath("git"); err == nil {
-		gitRevParseCmd := exec.Command("git", "-C", baseDir, "rev-parse", "--is-inside-work-tree")
-		var gitRevParseOut bytes.Buffer
-		gitRevParseCmd.Stdout = &gitRevParseOut
-		if gitRevParseCmd.Run() == nil && strings.TrimSpace(gitRevParseOut.String()) == "true" {
-			log.Printf("getFileListCommand: Using 'git ls-files' to get file list.")
-			return exec.Command("git", "-C", baseDir, "ls-files")
+// buildIndexCmd builds the file index and starts watching the tree for changes.
+// Building can take a while on large trees, so it runs in a goroutine like the searches.
+func buildIndexCmd(ix *index.Index, logger *slog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		if err := ix.Build(); err != nil {
+			logger.Error("Building the file index failed", logging.KeyError, err)
+			return IndexErrorMsg{Err: err}
+		}
+		// Build signals Changes; that signal is covered by IndexReadyMsg.
+		select {
+		case <-ix.Changes():
+		default:
 		}
+		if err := ix.Watch(); err != nil {
+			// Not fatal: search still works, it just won't see files changed after startup.
+			logger.Warn("Not watching for file changes", logging.KeyError, err)
+		}
+		return IndexReadyMsg{Files: ix.Len(), Ignored: ix.Ignored()}
+	}
+}
+
+// waitForIndexChangeCmd blocks until the index reports a change. It has to be
+// re-issued after every IndexChangedMsg to keep listening.
+func waitForIndexChangeCmd(ix *index.Index) tea.Cmd {
+	return func() tea.Msg {
+		<-ix.Changes()
+		return IndexChangedMsg{}
 	}
+}
 
-	log.Printf("getFileListCommand: Using 'rg --files' to get file list.")
-	cmd := exec.Command("rg", "--files", "--hidden", "--no-ignore", ".", "--max-depth", "100")
-	cmd.Dir = baseDir
-	return cmd
+// saveFrecencyCmd writes the frecency store to disk in the background.
+func saveFrecencyCmd(store *frecency.Store, logger *slog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Save(); err != nil {
+			logger.Warn("Saving frecency data failed", logging.KeyError, err)
+		}
+		return nil
+	}
 }
 
-// runFuzzySearchCmd executes fzf in non-interactive mode to get fuzzy-matched file paths
-// by streaming file list to it. This command runs in a goroutine and sends results
-// back to the main program loop.
-func runFuzzySearchCmd(query string, baseDir string) tea.Cmd {
+// runFuzzySearchCmd narrows the indexed file list down with the query's filters
+// and ranks what is left against its text with matcher (the built-in fuzzy
+// matcher unless another match mode was picked), boosting files that were
+// tagged often and recently (frecencyScores maps paths to their frecency
+// scores). With a git scope (scope is non-nil), only files in it are
+// considered. This command runs in a goroutine and sends results back to the
+// main program loop.
+func runFuzzySearchCmd(ctx context.Context, gen uint64, query search.Query, matcher *search.Matcher, scope map[string]bool, ix *index.Index, frecencyScores map[string]float64, logger *slog.Logger) tea.Cmd {
 	return func() tea.Msg { // This function now returns a message when done
-		fileListCmd := getFileListCommand(baseDir)
-		stdoutPipe, err := fileListCmd.StdoutPipe()
+		started := time.Now()
+		files := ix.Paths()
+		if scope != nil {
+			files = scopePaths(scope, files)
+		}
+		if query.HasFilters() {
+			files = filterPaths(query, ix, files, started)
+		}
+		matches, err := matcher.FindContext(ctx, files)
 		if err != nil {
-			log.Printf("runFuzzySearchCmd (Cmd func): Error creating stdout pipe for file list cmd: %v", err)
-			return FuzzySearchErrorMsg{Err: fmt.Errorf("failed to create pipe for file list: %w", err)}
+			// Superseded by a newer query; there is nothing to report.
+			logger.Debug("Fuzzy search cancelled", "gen", gen)
+			return nil
 		}
-		fileListCmd.Stderr = os.Stderr // Direct file list errors to main stderr for debugging
-
-		fzfArgs := []string{"--filter", query, "--print0"}
-		fzfCmd := exec.Command("fzf", fzfArgs...)
-		fzfCmd.Stdin = stdoutPipe // Pipe fileListCmd's stdout directly to fzf's stdin
-
-		var stdout, stderr bytes.Buffer
-		fzfCmd.Stdout = &stdout
-		fzfCmd.Stderr = &stderr
+		if len(frecencyScores) > 0 {
+			search.BoostMatches(matches, func(p string) int { return frecency.Boost(frecencyScores[p]) })
+		}
+		logger.Info("File search finished", "gen", gen, "match", matcher.Mode().String(), logging.KeyQuery, query.Text, "matches", len(matches), "candidates", len(files), "took", time.Since(started))
+		return FuzzySearchResultsMsg{Gen: gen, Matches: matches} // Send results back to the main Update loop
+	}
+}
 
-		// Start the file list command
-		if err := fileListCmd.Start(); err != nil {
-			log.Printf("runFuzzySearchCmd (Cmd func): Error starting file list command: %v", err)
-			stdoutPipe.Close() // Close pipe to prevent resource leak
-			return FuzzySearchErrorMsg{Err: fmt.Errorf("failed to start file list command: %w", err)}
+// runContentSearchCmd runs ripgrep for the query's text over the contents of the
+// files under baseDir, keeping only hits in files that pass the query's filters.
+// Like runFuzzySearchCmd it runs in a goroutine and reports back through a message,
+// and drops hits outside a non-nil scope. Cancelling ctx kills the ripgrep process.
+func runContentSearchCmd(ctx context.Context, gen uint64, query search.Query, scope map[string]bool, baseDir string, ix *index.Index, logger *slog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		started := time.Now()
+		logger.Debug("Running ripgrep", "gen", gen, logging.KeyQuery, query.Text)
+		matches, err := search.RunRipgrepContext(ctx, query.Text, baseDir)
+		if ctx.Err() != nil {
+			logger.Debug("Content search cancelled", "gen", gen)
+			return nil
 		}
-		log.Printf("runFuzzySearchCmd (Cmd func): Started file list generation for streaming to fzf.")
+		if err != nil {
+			logger.Warn("ripgrep failed", "gen", gen, logging.KeyError, err)
+			return SearchErrorMsg{Gen: gen, Err: err}
+		}
+		// ripgrep honours .gitignore itself but knows nothing about .promptyignore
+		// or the global ignore file, so check every hit against the index's rules.
+		now := time.Now()
+		kept := matches[:0]
+		for _, match := range matches {
+			if ix.IsIgnored(match.File, false) || (scope != nil && !scope[match.File]) {
+				continue
+			}
+			if !query.HasFilters() || matchesQuery(query, ix, match.File, now) {
+				kept = append(kept, match)
+			}
+		}
+		matches = kept
+		logger.Info("Content search finished", "gen", gen, logging.KeyQuery, query.Text, "matches", len(matches), "took", time.Since(started))
+		return ContentSearchResultsMsg{Gen: gen, Matches: matches}
+	}
+}
 
-		// Start the fzf command
-		if err := fzfCmd.Start(); err != nil {
-			log.Printf("runFuzzySearchCmd (Cmd func): Error starting fzf command: %v", err)
-			stdoutPipe.Close()         // Ensure pipe is closed if fzf fails to start
-			fileListCmd.Process.Kill() // Try to stop file list command
-			fileListCmd.Wait()
-			return FuzzySearchErrorMsg{Err: fmt.Errorf("failed to start fzf command: %w", err)}
+// runSymbolSearchCmd ranks the declarations of the indexed files against the
+// query's text with matcher, keeping only those in files that pass the query's
+// filters and are in a non-nil scope. Go files are parsed on first use and
+// whenever they change; see symbols.Table.
+func runSymbolSearchCmd(ctx context.Context, gen uint64, query search.Query, matcher *search.Matcher, scope map[string]bool, ix *index.Index, table *symbols.Table, logger *slog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		started := time.Now()
+		paths := ix.Paths()
+		files := make([]symbols.File, 0, len(paths))
+		for _, p := range paths {
+			if entry, ok := ix.Lookup(p); ok {
+				files = append(files, symbols.File{Path: p, Size: entry.Size, ModTime: entry.ModTime})
+			}
+		}
+		syms, err := table.Symbols(ctx, files)
+		if err != nil {
+			logger.Debug("Symbol search cancelled", "gen", gen)
+			return nil
 		}
-		log.Printf("runFuzzySearchCmd (Cmd func): Executing fzf --filter with query '%s'", query)
 
-		// Wait for both commands to finish.
-		var errs []error
+		if query.HasFilters() || scope != nil {
+			candidates := paths
+			if scope != nil {
+				candidates = scopePaths(scope, paths)
+			}
+			kept := make(map[string]bool)
+			for _, p := range filterPaths(query, ix, candidates, started) {
+				kept[p] = true
+			}
+			filtered := syms[:0]
+			for _, sym := range syms {
+				if kept[sym.Path] {
+					filtered = append(filtered, sym)
+				}
+			}
+			syms = filtered
+		}
 
-		fzfWaitErr := fzfCmd.Wait()
-		if fzfWaitErr != nil {
-			errs = append(errs, fmt.Errorf("fzf exited with error: %w (stderr: %s)", fzfWaitErr, stderr.String()))
+		names := make([]string, len(syms))
+		for i, sym := range syms {
+			names[i] = sym.Name
+		}
+		found, err := matcher.FindContext(ctx, names)
+		if err != nil {
+			logger.Debug("Symbol search cancelled", "gen", gen)
+			return nil
+		}
+		matches := make([]SymbolMatch, 0, min(len(found), maxContentResults))
+		for _, match := range found[:min(len(found), maxContentResults)] {
+			matches = append(matches, SymbolMatch{Symbol: syms[match.Index], MatchedIndexes: match.MatchedIndexes})
 		}
+		logger.Info("Symbol search finished", "gen", gen, "match", matcher.Mode().String(), logging.KeyQuery, query.Text, "matches", len(found), "symbols", len(syms), "took", time.Since(started))
+		return SymbolSearchResultsMsg{Gen: gen, Matches: matches}
+	}
+}
 
-		fileListWaitErr := fileListCmd.Wait()
-		if fileListWaitErr != nil {
-			errs = append(errs, fmt.Errorf("file list command exited with error: %w", fileListWaitErr))
+// scopePaths returns the paths that are in scope, in their original order.
+func scopePaths(scope map[string]bool, paths []string) []string {
+	kept := make([]string, 0, len(scope))
+	for _, p := range paths {
+		if scope[p] {
+			kept = append(kept, p)
 		}
-		stdoutPipe.Close() // Explicitly close the pipe after both commands are done
+	}
+	return kept
+}
 
-		if len(errs) > 0 {
-			finalErr := fmt.Errorf("fuzzy search process errors: %v", errs)
-			log.Printf("runFuzzySearchCmd (Cmd func): Errors during execution: %v", finalErr)
-			return FuzzySearchErrorMsg{Err: finalErr}
+// filterPaths returns the paths that pass the query's filters.
+func filterPaths(query search.Query, ix *index.Index, paths []string, now time.Time) []string {
+	filtered := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if matchesQuery(query, ix, p, now) {
+			filtered = append(filtered, p)
 		}
+	}
+	return filtered
+}
 
-		// Parse the null-terminated output from fzf
-		rawPaths := bytes.Split(stdout.Bytes(), []byte{0x00})
-		var matchedPaths []string
-		for _, p := range rawPaths {
-			path := string(p)
-			if strings.TrimSpace(path) != "" {
-				matchedPaths = append(matchedPaths, path)
-			}
+// matchesQuery reports whether the file at relPath passes the query's filters.
+// Size and modification time come from the index, or from disk for files it
+// doesn't know (yet), and are only looked up when a filter needs them.
+func matchesQuery(query search.Query, ix *index.Index, relPath string, now time.Time) bool {
+	var size int64
+	var modTime time.Time
+	if query.NeedsStat() {
+		if entry, ok := ix.Lookup(relPath); ok {
+			size, modTime = entry.Size, entry.ModTime
+		} else if info, err := os.Stat(ix.Abs(relPath)); err == nil {
+			size, modTime = info.Size(), info.ModTime()
+		} else {
+			return false
 		}
-		log.Printf("runFuzzySearchCmd (Cmd func): fzf --filter returned %d matched paths.", len(matchedPaths))
-		return FuzzySearchResultsMsg(matchedPaths) // Send results back to the main Update loop
 	}
+	return query.Match(relPath, size, modTime, now)
 }
 
-// readFileContent is a helper function to read the entire content of a file from disk.
-func readFileContent(filePath string) (string, error) {
-	log.Printf("readFileContent: Attempting to read file: %s", filePath)
-	content, err := ioutil.ReadFile(filePath)
+// startSearch cancels any in-flight search and starts a new one for input in the
+// current mode. The input is parsed into filters and search text first; a
+// malformed filter is shown as an error instead of searching. Each search gets
+// a new generation; results carrying an older generation are discarded when they arrive.
+func (m *SearchModel) startSearch(input string) tea.Cmd {
+	m.cancelActiveSearch()
+	m.searchGen++
+	m.err = nil
+
+	query, err := search.ParseQuery(input)
 	if err != nil {
-		log.Printf("readFileContent: Error reading file %s: %v", filePath, err)
-		return "", err
+		m.logger.Debug("Invalid query", logging.KeyQuery, input, logging.KeyError, err)
+		m.err = err
+		return nil
 	}
-	log.Printf("readFileContent: Successfully read %d bytes from %s.", len(content), filePath)
-	return string(content), nil
+	if m.mode == ContentSearchMode && query.Text == "" {
+		m.err = fmt.Errorf("content search needs a pattern besides the filters")
+		return nil
+	}
+
+	var matcher *search.Matcher
+	if m.mode != ContentSearchMode {
+		// Content search hands its pattern to ripgrep; the others compile it here,
+		// so a broken regex or glob is reported before anything runs.
+		if matcher, err = search.NewMatcher(m.match, query.Text); err != nil {
+			m.logger.Debug("Invalid pattern", "match", m.match.String(), logging.KeyQuery, query.Text, logging.KeyError, err)
+			m.err = err
+			return nil
+		}
+	}
+
+	if m.mode != ContentSearchMode && !m.indexReady {
+		// The search is re-run once IndexReadyMsg arrives.
+		m.querying = true
+		return nil
+	}
+	if !m.scopeReady() {
+		// The search is re-run once ScopeFilesMsg arrives.
+		m.querying = true
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSearch = cancel
+	m.querying = true
+	switch m.mode {
+	case ContentSearchMode:
+		return runContentSearchCmd(ctx, m.searchGen, query, m.scopeSet, m.baseDir, m.index, m.logger)
+	case SymbolSearchMode:
+		return runSymbolSearchCmd(ctx, m.searchGen, query, matcher, m.scopeSet, m.index, m.symbols, m.logger)
+	}
+	return runFuzzySearchCmd(ctx, m.searchGen, query, matcher, m.scopeSet, m.index, m.frecency.Scores(m.baseDir, time.Now()), m.logger)
+}
+
+// cancelActiveSearch stops the in-flight search, if any, and invalidates its results.
+func (m *SearchModel) cancelActiveSearch() {
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+		m.cancelSearch = nil
+	}
+	m.searchGen++
+	m.querying = false
+}
+
+// finishSearch reports whether a result for generation gen is current, and if
+// so marks the search as complete.
+func (m *SearchModel) finishSearch(gen uint64) bool {
+	if gen != m.searchGen {
+		m.logger.Debug("Dropping stale search results", "gen", gen, "current", m.searchGen)
+		return false
+	}
+	if m.cancelSearch != nil {
+		m.cancelSearch() // Release the context's resources
+		m.cancelSearch = nil
+	}
+	m.querying = false
+	return true
 }
 
 // loadFileContentCmd creates a Bubble Tea command to load file content asynchronously.
-// This prevents blocking the UI while reading potentially large files.
+// This prevents blocking the UI while reading potentially large files. The read
+// goes through the model's content loader, which caps how many run at once.
+// Use loadContent rather than calling this directly, so the load is tracked.
 func (m *SearchModel) loadFileContentCmd(filePath string) tea.Cmd {
+	ctx, loader, ix, indexReady, logger := m.loadCtx, m.loader, m.index, m.indexReady, m.logger
 	return func() tea.Msg {
-		// IMPORTANT: filePath from fzf should generally be relative to baseDir.
-		fullPath := filepath.Join(m.baseDir, filePath)
-		log.Printf("loadFileContentCmd: Triggered for path: %s (full: %s)", filePath, fullPath)
-		content, err := readFileContent(fullPath)
+		// IMPORTANT: filePath from the index is relative to baseDir.
+		if _, ok := ix.Lookup(filePath); !ok && indexReady {
+			logger.Warn("Not loading file missing from the index", logging.KeyPath, filePath)
+			return fileContentErrorMsg{Path: filePath, Err: fmt.Errorf("%s is no longer in the project", filePath)}
+		}
+		fullPath := ix.Abs(filePath)
+		file, err := loader.Load(ctx, fullPath)
 		if err != nil {
-			log.Printf("loadFileContentCmd: Error in readFileContent for %s: %v", filePath, err)
+			logger.Warn("Loading file content failed", logging.KeyPath, filePath, logging.KeyError, err)
 			return fileContentErrorMsg{Path: filePath, Err: err}
 		}
-		log.Printf("loadFileContentCmd: Content loaded for %s.", filePath)
-		return fileContentMsg{Path: filePath, Content: content}
+		if file.Kind != content.Text {
+			// Binary and oversized files are represented by a placeholder instead of raw bytes.
+			logger.Info("Omitting file content", logging.KeyPath, filePath, "reason", file.Placeholder())
+			return fileContentMsg{Path: filePath, Omitted: file.Placeholder()}
+		}
+		logger.Debug("Loaded file content", logging.KeyPath, filePath, "bytes", len(file.Text))
+		return fileContentMsg{Path: filePath, Content: file.Text}
+	}
+}
+
+// loadContent starts loading a file's content unless a load for it is already
+// under way, and counts it towards the loading progress shown in the status line.
+func (m *SearchModel) loadContent(filePath string) tea.Cmd {
+	if m.loading[filePath] {
+		return nil
+	}
+	m.loading[filePath] = true
+	delete(m.loadFailed, filePath)
+	m.loadTotal++
+	return m.loadFileContentCmd(filePath)
+}
+
+// finishLoad records that the load for filePath completed (successfully or not).
+// Once nothing is left loading, the progress counters start over and the
+// tagged files are put in order, now that all their contents are known.
+func (m *SearchModel) finishLoad(filePath string) {
+	if !m.loading[filePath] {
+		return
+	}
+	delete(m.loading, filePath)
+	m.loadDone++
+	if len(m.loading) == 0 {
+		m.loadDone, m.loadTotal = 0, 0
+		m.applyFileOrder()
 	}
 }
 
+// showTaggedFiles replaces the results with just the tagged files, all pinned.
+func (m *SearchModel) showTaggedFiles() {
+	m.clearSelection()
+	m.results = m.GetTaggedFiles()
+	m.pinned = len(m.results)
+	m.cursor = 0
+	m.resultsViewport.GotoTop()
+}
+
+// sortResults orders the unpinned results according to m.sortOrder.
+// Sorting is stable, so equal keys keep the matcher's ranking.
+func (m *SearchModel) sortResults() {
+	unpinned := m.results[m.pinned:]
+	sort.SliceStable(unpinned, func(i, j int) bool {
+		a, b := unpinned[i], unpinned[j]
+		switch m.sortOrder {
+		case SortByPath:
+			return a.Path < b.Path
+		case SortByModTime:
+			ea, _ := m.index.Lookup(a.Path)
+			eb, _ := m.index.Lookup(b.Path)
+			return ea.ModTime.After(eb.ModTime)
+		case SortBySize:
+			ea, _ := m.index.Lookup(a.Path)
+			eb, _ := m.index.Lookup(b.Path)
+			return ea.Size > eb.Size
+		default:
+			return a.Rank < b.Rank
+		}
+	})
+}
+
+// resultLine returns the line of the results viewport that shows result i,
+// accounting for the section headers drawn around the pinned results.
+func (m *SearchModel) resultLine(i int) int {
+	if m.pinned == 0 {
+		return i
+	}
+	if i < m.pinned {
+		return i + 1 // Below the "Tagged" header
+	}
+	return i + 2 // Below both headers
+}
+
+// ensureCursorVisible scrolls the results viewport just enough to show the cursor.
+func (m *SearchModel) ensureCursorVisible() {
+	line := m.resultLine(m.cursor)
+	if m.cursor == 0 {
+		line = 0 // Keep the first section header in view too
+	}
+	if line < m.resultsViewport.YOffset {
+		m.resultsViewport.SetYOffset(line)
+	} else if height := m.resultsViewport.Height; height > 0 && line >= m.resultsViewport.YOffset+height {
+		m.resultsViewport.SetYOffset(line - height + 1)
+	}
+}
+
+// Typing reports whether digits are part of what is being typed: a query
+// that has been started, so filters like size:<20k work, or the input of the
+// Ctrl+O group dialog or the Ctrl+R/Alt+S history picker. App then passes
+// digits through instead of switching tabs. With an empty query they still
+// switch tabs, so a query can't start with a digit.
+func (m *SearchModel) Typing() bool {
+	return m.textInput.Value() != "" || m.dialogOpen()
+}
+
+// dialogOpen reports whether the group dialog or the history picker is open;
+// it gets every key, Tab included, until it is closed.
+func (m *SearchModel) dialogOpen() bool {
+	return m.group.active || m.picker.active
+}
+
 // GetTaggedFiles returns a slice of FileItem objects that are currently tagged by the user.
 // This now returns from the persistent list of all tagged files.
 func (m *SearchModel) GetTaggedFiles() []FileItem {
 	// Return a copy to prevent external modifications
 	copiedFiles := make([]FileItem, len(m.allTaggedFiles))
 	copy(copiedFiles, m.allTaggedFiles)
-	log.Printf("SearchModel: GetTaggedFiles called, returning %d tagged files from persistent store.", len(copiedFiles))
 	return copiedFiles
 }
 
+// isTagged reports whether path is in the persistent list of tagged files.
+func (m *SearchModel) isTagged(path string) bool {
+	for _, taggedFile := range m.allTaggedFiles {
+		if taggedFile.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// isUntracked reports whether the index knows path as a file git doesn't track yet.
+func (m *SearchModel) isUntracked(path string) bool {
+	entry, ok := m.index.Lookup(path)
+	return ok && entry.Untracked
+}
+
+// tagFile adds a copy of item to the persistent allTaggedFiles list unless its
+// path is already there, and returns a command loading its content if that
+// isn't loaded yet. It reports whether the file was newly added.
+func (m *SearchModel) tagFile(item FileItem) (tea.Cmd, bool) {
+	if m.isTagged(item.Path) {
+		return nil, false
+	}
+	var cmd tea.Cmd
+	if item.Content == "" && item.Omitted == "" { // If content is not loaded yet, schedule it
+		cmd = m.loadContent(item.Path)
+	}
+	item.Tagged = true
+	item.MatchedIndexes = nil // Highlights belong to the current query only
+	item.Untracked = m.isUntracked(item.Path)
+	m.allTaggedFiles = append(m.allTaggedFiles, item)
+	m.applyFileOrder()
+	m.logger.Info("Tagged file", logging.KeyPath, item.Path)
+	return cmd, true
+}
+
+// tagPaths tags the files given on the command line with --tag once the index
+// is ready. Paths are relative to baseDir (absolute paths inside it work too);
+// ones that don't name a file in the project are reported in m.err.
+func (m *SearchModel) tagPaths(paths []string) tea.Cmd {
+	var cmds []tea.Cmd
+	var missing []string
+	for _, p := range paths {
+		if filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(m.baseDir, p); err == nil {
+				p = rel
+			}
+		}
+		p = filepath.ToSlash(filepath.Clean(p))
+		if _, indexed := m.index.Lookup(p); !indexed {
+			// Not listed (e.g. ignored by git), but tag it anyway if it's a file on disk.
+			if info, err := os.Stat(m.index.Abs(p)); err != nil || info.IsDir() {
+				missing = append(missing, p)
+				continue
+			}
+		}
+		if cmd, added := m.tagFile(FileItem{Path: p}); added {
+			cmds = append(cmds, cmd)
+		}
+	}
+	if len(missing) > 0 {
+		m.err = fmt.Errorf("could not tag %s: no such file in %s", strings.Join(missing, ", "), m.baseDir)
+	}
+	m.logger.Info("Tagged files from the command line", "tagged", len(paths)-len(missing), "requested", len(paths))
+	cmds = append(cmds, func() tea.Msg { return TaggedFilesMsg(m.GetTaggedFiles()) })
+	return tea.Batch(cmds...)
+}
+
 // UntagFileByPath removes a file from the persistent allTaggedFiles list.
 func (m *SearchModel) UntagFileByPath(path string) {
-	log.Printf("SearchModel: Attempting to untag %s by path.", path)
 	newAllTaggedFiles := []FileItem{}
 	for _, taggedFile := range m.allTaggedFiles {
 		if taggedFile.Path != path {
 			newAllTaggedFiles = append(newAllTaggedFiles, taggedFile)
 		} else {
-			log.Printf("SearchModel: Found and removed %s from allTaggedFiles.", path)
+			m.logger.Info("Untagged file", logging.KeyPath, path)
 		}
 	}
 	m.allTaggedFiles = newAllTaggedFiles
@@ -261,50 +801,237 @@ func (m *SearchModel) UntagFileByPath(path string) {
 	for i := range m.results {
 		if m.results[i].Path == path {
 			m.results[i].Tagged = false
-			log.Printf("SearchModel: Updated tagged status for %s in current results to false.", path)
 			break
 		}
 	}
 }
 
-// Update handles messages for the SearchModel.
+// SetFileRanges replaces the tagged line ranges of a tagged file; nil tags the
+// whole file. It is how lines tagged in the Browse preview reach the
+// persistent list.
+func (m *SearchModel) SetFileRanges(path string, ranges []content.LineRange) {
+	for i := range m.allTaggedFiles {
+		if m.allTaggedFiles[i].Path == path {
+			m.allTaggedFiles[i].Ranges = ranges
+			m.logger.Info("Tagged line ranges", logging.KeyPath, path, "ranges", content.FormatRanges(ranges))
+			break
+		}
+	}
+
+	// The displayed results show a tagged file's ranges too.
+	for i := range m.results {
+		if m.results[i].Path == path && m.results[i].Symbol == nil && m.results[i].Tagged {
+			m.results[i].Ranges = ranges
+		}
+	}
+	m.refreshSymbolResults(path)
+}
+
+// symbolTagged reports whether a symbol result's lines are part of the prompt,
+// either because its file is tagged whole or because they are among its ranges.
+func (m *SearchModel) symbolTagged(item FileItem) bool {
+	for _, tagged := range m.allTaggedFiles {
+		if tagged.Path == item.Path {
+			return len(tagged.Ranges) == 0 || content.Covers(tagged.Ranges, item.Symbol.Lines)
+		}
+	}
+	return false
+}
+
+// setResultTagged tags or untags result i, keeping the other results for the
+// same file in sync, and returns the command loading the file's content if it
+// needs loading. A symbol result tags or untags just its declaration's lines.
+// Newly tagged files are recorded in the frecency store; saving it is up to
+// the caller. It fails when tagging a file that no longer exists.
+func (m *SearchModel) setResultTagged(i int, tagged bool) (tea.Cmd, error) {
+	item := m.results[i]
+	if item.Tagged == tagged {
+		return nil, nil
+	}
+	if _, indexed := m.index.Lookup(item.Path); tagged && m.indexReady && !indexed {
+		// The file was deleted or renamed since it was listed.
+		return nil, fmt.Errorf("%s no longer exists", item.Path)
+	}
+	if item.Symbol != nil {
+		return m.setSymbolTagged(item, tagged)
+	}
+
+	m.logger.Debug("Toggled tag", logging.KeyPath, item.Path, "tagged", tagged)
+	// Content search can list several hits for the same file; keep them in sync.
+	for j := range m.results {
+		if m.results[j].Path == item.Path && m.results[j].Symbol == nil {
+			m.results[j].Tagged = tagged
+		}
+	}
+
+	// Update m.allTaggedFiles (the persistent store) based on the toggle
+	if !tagged {
+		m.UntagFileByPath(item.Path)
+		m.refreshSymbolResults(item.Path)
+		return nil, nil
+	}
+	loadCmd, added := m.tagFile(item)
+	if added {
+		m.frecency.Touch(m.baseDir, item.Path, time.Now())
+	}
+	m.refreshSymbolResults(item.Path)
+	return loadCmd, nil
+}
+
+// setSymbolTagged adds a symbol result's line range to its file's tagged
+// ranges, tagging the file if needed, or takes the range out again. A file
+// tagged whole keeps every other line, like untagLines in the Browse preview.
+// Taking out a file's last lines is refused; Ctrl+A on the file untags it.
+func (m *SearchModel) setSymbolTagged(item FileItem, tagged bool) (tea.Cmd, error) {
+	var loadCmd tea.Cmd
+	lines := item.Symbol.Lines
+	switch {
+	case !tagged:
+		for i := range m.allTaggedFiles {
+			file := &m.allTaggedFiles[i]
+			if file.Path != item.Path {
+				continue
+			}
+			current := file.Ranges
+			if len(current) == 0 {
+				// Tagged whole: the rest of the file stays tagged, which needs its length.
+				switch {
+				case m.loading[file.Path]:
+					return nil, fmt.Errorf("%s is still loading; try again in a moment", file.Path)
+				case m.loadFailed[file.Path]:
+					return nil, fmt.Errorf("%s could not be read, so its lines are unknown; press Ctrl+A on the file to untag it", file.Path)
+				case file.Omitted != "":
+					return nil, fmt.Errorf("%s is left out of the prompt (%s), so it has no lines to keep; press Ctrl+A on the file to untag it", file.Path, file.Omitted)
+				}
+				current = []content.LineRange{{Start: 1, End: content.LineCount(file.Content)}}
+			}
+			ranges := content.RemoveRange(current, lines)
+			if len(ranges) == 0 {
+				return nil, fmt.Errorf("that would leave no lines of %s; press Ctrl+A on the file to untag it", file.Path)
+			}
+			m.SetFileRanges(file.Path, ranges) // Also shows the ranges on the file's own results
+			break
+		}
+		m.logger.Debug("Untagged symbol", logging.KeyPath, item.Path, "symbol", item.Symbol.Name)
+	case m.isTagged(item.Path):
+		for i := range m.allTaggedFiles {
+			if m.allTaggedFiles[i].Path == item.Path {
+				m.allTaggedFiles[i].Ranges = content.MergeRanges(append(m.allTaggedFiles[i].Ranges, lines))
+				break
+			}
+		}
+		m.logger.Debug("Tagged symbol", logging.KeyPath, item.Path, "symbol", item.Symbol.Name)
+	default:
+		loadCmd, _ = m.tagFile(FileItem{Path: item.Path, Ranges: []content.LineRange{lines}})
+		m.frecency.Touch(m.baseDir, item.Path, time.Now())
+		m.logger.Debug("Tagged symbol", logging.KeyPath, item.Path, "symbol", item.Symbol.Name)
+	}
+	m.refreshSymbolResults(item.Path)
+	return loadCmd, nil
+}
+
+// refreshSymbolResults recomputes the tagged mark of the symbol results in a
+// file, since tagging one declaration (or the whole file) can cover others.
+func (m *SearchModel) refreshSymbolResults(path string) {
+	for i := range m.results {
+		if m.results[i].Path == path && m.results[i].Symbol != nil {
+			m.results[i].Tagged = m.symbolTagged(m.results[i])
+		}
+	}
+}
+
+// Update handles messages for the SearchModel, then lets the preview pane
+// follow the highlighted result, which nearly any message can change.
 func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if previewCmd := m.syncPreview(); previewCmd != nil {
+		cmd = tea.Batch(cmd, previewCmd)
+	}
+	return model, cmd
+}
+
+// update handles messages for the SearchModel; see Update.
+func (m *SearchModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
-	log.Printf("SearchModel Update received message: %T", msg)
+	m.logger.Debug("Update", logging.KeyMsgType, fmt.Sprintf("%T", msg))
+
+	// While the group dialog or the history picker is open, it gets every key press.
+	if kMsg, isKeyMsg := msg.(tea.KeyMsg); isKeyMsg && m.group.active {
+		return m, m.updateGroupPrompt(kMsg)
+	} else if m.group.active {
+		m.group.input, cmd = m.group.input.Update(msg) // Keep its cursor blinking
+		cmds = append(cmds, cmd)
+	}
+	if kMsg, isKeyMsg := msg.(tea.KeyMsg); isKeyMsg && m.picker.active {
+		return m, m.updateHistoryPicker(kMsg)
+	} else if m.picker.active {
+		m.picker.input, cmd = m.picker.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
 	// Handle specific key messages that should bypass textInput/viewport processing
 	if kMsg, isKeyMsg := msg.(tea.KeyMsg); isKeyMsg {
+		switch kMsg.String() { // Bulk tagging; see selection.go
+		case "alt+a":
+			return m, m.tagAll()
+		case "alt+u":
+			return m, m.untagTargets()
+		case "alt+i":
+			return m, m.invertTargets()
+		case "alt+s": // Alt+S saves the query under a name; see history.go
+			return m, m.openSaveQueryPrompt()
+		case "alt+p": // Alt+P shows or hides the preview pane; see preview.go
+			m.togglePreview()
+			return m, nil
+		case "alt+j", "alt+k", "alt+J", "alt+K": // Scroll the preview pane
+			if m.scrollPreview(kMsg.String()) {
+				return m, nil
+			}
+		case "alt+t": // Alt+T tags every file in the git scope
+			return m, m.tagScope()
+		case "alt+m": // Alt+M cycles how file names and symbols are matched
+			if m.mode == ContentSearchMode {
+				m.err = fmt.Errorf("content search always takes a ripgrep regex; match modes apply to file and symbol search")
+				return m, nil
+			}
+			m.match = m.match.Next()
+			m.logger.Debug("Match mode changed", "match", m.match.String())
+			m.err = nil
+			if query := m.textInput.Value(); query != "" {
+				return m, m.startSearch(query)
+			}
+			return m, nil
+		}
 		switch kMsg.Type {
+		case tea.KeyCtrlG: // Ctrl+G cycles the git scope searches are limited to
+			return m, m.cycleScope()
+		case tea.KeyCtrlR: // Ctrl+R recalls an earlier or saved query
+			return m, m.openHistoryPicker()
+		case tea.KeyCtrlX: // Ctrl+X starts or drops a range selection
+			m.toggleSelection()
+			return m, nil
+		case tea.KeyCtrlO: // Ctrl+O tags a whole directory or glob
+			if !m.indexReady {
+				m.err = fmt.Errorf("the file index is still being built")
+				return m, nil
+			}
+			return m, m.openGroupPrompt()
 		case tea.KeyCtrlA: // Handle Ctrl+A for tagging first, to prevent cursor reset
-			log.Printf("SearchModel: Ctrl+A pressed (tag/untag).")
+			if m.selecting {
+				// With a range selected, Ctrl+A tags all of it (or untags it, if it is all tagged).
+				return m, m.tagSelection()
+			}
 			if m.cursor >= 0 && m.cursor < len(m.results) {
-				fileToModify := &m.results[m.cursor]
-				fileToModify.Tagged = !fileToModify.Tagged // Toggle status in current results
-				log.Printf("SearchModel: Toggled tag for %s. New status in m.results: %v", fileToModify.Path, fileToModify.Tagged)
-
-				// Update m.allTaggedFiles (the persistent store) based on the toggle
-				if fileToModify.Tagged {
-					// Add to allTaggedFiles if it's not already there
-					foundInAllTagged := false
-					for _, taggedFile := range m.allTaggedFiles {
-						if taggedFile.Path == fileToModify.Path {
-							foundInAllTagged = true
-							break
-						}
-					}
-					if !foundInAllTagged {
-						// Ensure content is copied if available to the persistent store.
-						if fileToModify.Content == "" { // If content is not loaded yet, schedule it
-							cmds = append(cmds, m.loadFileContentCmd(fileToModify.Path))
-						}
-						m.allTaggedFiles = append(m.allTaggedFiles, *fileToModify) // Append a copy
-						log.Printf("SearchModel: Added %s to allTaggedFiles (persistent store).", fileToModify.Path)
-					}
-				} else {
-					// Remove from allTaggedFiles
-					m.UntagFileByPath(fileToModify.Path) // Use the new untag method
+				loadCmd, err := m.setResultTagged(m.cursor, !m.results[m.cursor].Tagged)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				cmds = append(cmds, loadCmd)
+				if m.results[m.cursor].Tagged {
+					cmds = append(cmds, saveFrecencyCmd(m.frecency, m.logger), m.rememberQuery())
 				}
 
 				// Always send message to App to update global tagged files
@@ -314,8 +1041,38 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, tea.Batch(cmds...) // Return early after handling Ctrl+A
 		case tea.KeyCtrlQ:
-			log.Printf("SearchModel: Key 'Ctrl+Q' pressed. Quitting application.")
+			m.logger.Info("Ctrl+Q pressed, quitting")
 			return m, tea.Quit // Quit the application
+		case tea.KeyCtrlT: // Ctrl+T cycles between file name, content and symbol search
+			m.mode = m.mode.next()
+			m.logger.Debug("Search mode changed", "mode", m.mode.String())
+			m.textInput.Placeholder = m.placeholder()
+			m.showTaggedFiles()
+			m.err = nil
+			if query := m.textInput.Value(); query != "" {
+				return m, m.startSearch(query)
+			}
+			m.cancelActiveSearch()
+			return m, nil
+		case tea.KeyCtrlS: // Ctrl+S cycles how results are sorted
+			m.clearSelection()
+			m.sortOrder = m.sortOrder.next()
+			m.logger.Debug("Sort order changed", "sort", m.sortOrder.String())
+			if m.cursor >= 0 && m.cursor < len(m.results) {
+				// Keep the cursor on the same result after re-sorting.
+				current := m.results[m.cursor]
+				m.sortResults()
+				for i := range m.results {
+					if m.results[i].Path == current.Path && m.results[i].OriginalMatch == current.OriginalMatch && m.results[i].Symbol == current.Symbol {
+						m.cursor = i
+						break
+					}
+				}
+			} else {
+				m.sortResults()
+			}
+			m.ensureCursorVisible()
+			return m, nil
 		}
 	}
 
@@ -328,168 +1085,176 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// reset the debounce timer and trigger a fuzzy search.
 	if m.textInput.Focused() && oldTextInputValue != m.textInput.Value() {
 		m.lastUpdate = time.Now()
-		// We'll trigger the fuzzy search on debounce.
-		if !m.querying {
-			cmds = append(cmds, debounceCmd(300*time.Millisecond))
-			log.Printf("SearchModel: Text input changed, starting debounce for fuzzy search.")
-		}
+		// We'll trigger the search on debounce; it cancels any search still running.
+		cmds = append(cmds, debounceCmd(300*time.Millisecond))
 	}
 
 	// Handle other messages
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		log.Printf("SearchModel: WindowSizeMsg received. Width: %d, Height: %d", msg.Width, msg.Height)
-
-		totalAvailableWidth := msg.Width
-		// Since there is no preview panel, the left panel can take the full width
-		usableContentWidth := totalAvailableWidth
-
-		if usableContentWidth < 0 { // Prevent negative width
-			usableContentWidth = 0
-		}
-
-		// Estimate fixed UI height in search tab:
-		// Search title: 1 line
-		// Search text input: 1 line
-		// Spacer: 1 line
-		// Help text: 1 line
-		// Status section: 1 line
-		// Results title: 1 line
-		// Spacers: 2 lines
-		// Total rough fixed height: 1+1+1+1+1+1+2 = 8 lines
-		minFixedUiHeight := 8
-
-		availableResultsHeight := msg.Height - minFixedUiHeight
-		if availableResultsHeight < 5 { // Ensure minimum height for results viewport
-			availableResultsHeight = 5
-		}
-
-		// Update dimensions for text input and results viewport
-		m.textInput.Width = usableContentWidth
-		m.resultsViewport.Width = usableContentWidth
-		m.resultsViewport.Height = availableResultsHeight
-		log.Printf("SearchModel: Resized text input to W:%d H:%d",
-			m.textInput.Width, m.resultsViewport.Width, m.resultsViewport.Height)
-
-		// Delegate WindowSizeMsg to text input and viewport
-		m.textInput, cmd = m.textInput.Update(msg)
-		cmds = append(cmds, cmd)
-		m.resultsViewport, cmd = m.resultsViewport.Update(msg)
-		cmds = append(cmds, cmd)
+		// App sends the room the tab gets inside its frame, not the terminal size.
+		m.logger.Debug("Window resized", "width", msg.Width, "height", msg.Height)
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
 		return m, tea.Batch(cmds...)
 
 	case tea.KeyMsg: // Only general key handling, Ctrl+A/Ctrl+Q already handled above
 		switch msg.Type {
 		case tea.KeyEnter:
-			log.Printf("SearchModel: Enter key pressed.")
 			// If there's a query, trigger fuzzy search. Otherwise, if query is empty, just show all tagged files.
 			if m.textInput.Value() != "" {
 				query := m.textInput.Value()
-				m.err = nil
-				m.querying = true
-				cmds = append(cmds, runFuzzySearchCmd(query, m.baseDir))
-				log.Printf("SearchModel: Triggering fuzzy search on Enter for query: '%s'.", query)
+				cmds = append(cmds, m.startSearch(query), m.rememberQuery())
+				m.logger.Debug("Search triggered by Enter", "mode", m.mode.String(), logging.KeyQuery, query)
 			} else {
 				// If query is empty, pressing Enter will display all tagged files.
-				m.results = m.GetTaggedFiles()
+				m.cancelActiveSearch()
+				m.showTaggedFiles()
 				m.err = nil
-				m.querying = false
-				m.cursor = 0
 				cmds = append(cmds, func() tea.Msg { return TaggedFilesMsg(m.GetTaggedFiles()) })
-				log.Printf("SearchModel: Input empty, showing all tagged files on Enter.")
 			}
 		case tea.KeyEsc:
-			log.Printf("SearchModel: Esc key pressed.")
+			if m.selecting {
+				// First Esc only drops the range selection.
+				m.clearSelection()
+				break
+			}
 			// Clear the search query and show all currently tagged files (persistent store)
 			m.textInput.SetValue("")
-			m.results = m.GetTaggedFiles() // Display only currently tagged files after clearing search
+			m.cancelActiveSearch() // Results of a search still running are no longer wanted
+			m.showTaggedFiles()    // Display only currently tagged files after clearing search
 			m.err = nil
-			m.querying = false
-			m.cursor = 0
-			// Reset viewport offset to top when clearing search
-			m.resultsViewport.GotoTop()
 			cmds = append(cmds, func() tea.Msg { return TaggedFilesMsg(m.GetTaggedFiles()) })
-			log.Printf("SearchModel: Search query cleared via Esc. Displaying all tagged files.")
+			m.logger.Debug("Search cleared via Esc")
 		case tea.KeyCtrlN: // Ctrl+N for navigating down (custom handling)
-			log.Printf("SearchModel: Ctrl+N pressed (down).")
 			if len(m.results) > 0 {
 				m.cursor = (m.cursor + 1) % len(m.results)
-				m.resultsViewport.SetYOffset(m.cursor) // Corrected: Use SetYOffset for viewport scrolling
-				log.Printf("SearchModel: Cursor moved to %d. Viewport scrolled.", m.cursor)
+				m.ensureCursorVisible()
 			}
 		case tea.KeyCtrlP: // Ctrl+P for navigating up (custom handling)
-			log.Printf("SearchModel: Ctrl+P pressed (up).")
 			if len(m.results) > 0 {
 				m.cursor = (m.cursor - 1 + len(m.results)) % len(m.results)
-				m.resultsViewport.SetYOffset(m.cursor) // Corrected: Use SetYOffset for viewport scrolling
-				log.Printf("SearchModel: Cursor moved to %d. Viewport scrolled.", m.cursor)
+				m.ensureCursorVisible()
 			}
 		}
 
 	case tea.MouseMsg: // Delegate mouse events to the viewport
-		log.Printf("SearchModel: MouseMsg received: %s", msg.String())
 		m.resultsViewport, cmd = m.resultsViewport.Update(msg)
 		cmds = append(cmds, cmd)
 
 	case MsgDebouncedSearch:
-		log.Printf("SearchModel: MsgDebouncedSearch received.")
 		if time.Since(m.lastUpdate) >= 300*time.Millisecond {
 			query := m.textInput.Value()
 			m.err = nil
-			m.querying = true // Indicate search is active
-			cmds = append(cmds, runFuzzySearchCmd(query, m.baseDir))
-			log.Printf("SearchModel: Debounced fuzzy search triggered for query: '%s'.", query)
-		} else {
-			log.Printf("SearchModel: Debounced search received, but not enough time passed (%.0fms since last update). Skipping.", time.Since(m.lastUpdate).Milliseconds())
+			if m.mode == ContentSearchMode && query == "" {
+				// An empty pattern would match every line of every file; show tagged files instead.
+				m.cancelActiveSearch()
+				m.showTaggedFiles()
+				break
+			}
+			cmds = append(cmds, m.startSearch(query))
+			m.logger.Debug("Debounced search triggered", "mode", m.mode.String(), logging.KeyQuery, query)
+		}
+	case IndexReadyMsg:
+		m.logger.Debug("File index ready", "files", msg.Files, "ignored", msg.Ignored)
+		m.indexReady = true
+		cmds = append(cmds, waitForIndexChangeCmd(m.index))
+		if len(m.pendingTags) > 0 {
+			cmds = append(cmds, m.tagPaths(m.pendingTags))
+			m.pendingTags = nil
+			if m.textInput.Value() == "" {
+				m.showTaggedFiles()
+			}
+		}
+		// Anything typed while the index was building is searched now.
+		if query := m.textInput.Value(); query != "" && m.mode != ContentSearchMode {
+			cmds = append(cmds, m.startSearch(query))
+		} else if m.mode != ContentSearchMode {
+			m.querying = false
+		}
+		return m, tea.Batch(cmds...)
+
+	case IndexChangedMsg:
+		m.logger.Debug("File index changed", "files", m.index.Len())
+		cmds = append(cmds, waitForIndexChangeCmd(m.index))
+		// Refresh the visible results so created, deleted and renamed files
+		// (and, in symbol mode, edited declarations) show up. Edits can also
+		// move files in or out of a git scope, so list it again first.
+		if cmd := m.reloadScope(); cmd != nil {
+			cmds = append(cmds, cmd)
+		} else if query := m.textInput.Value(); query != "" && m.mode != ContentSearchMode {
+			cmds = append(cmds, m.startSearch(query))
+		}
+		return m, tea.Batch(cmds...)
+
+	case previewContentMsg:
+		m.handlePreviewContent(msg)
+		return m, tea.Batch(cmds...)
+
+	case ScopeFilesMsg:
+		cmds = append(cmds, m.handleScopeFiles(msg))
+		return m, tea.Batch(cmds...)
+
+	case IndexErrorMsg:
+		if len(m.pendingTags) > 0 {
+			// Without an index, tag whatever exists on disk.
+			cmds = append(cmds, m.tagPaths(m.pendingTags))
+			m.pendingTags = nil
+			m.showTaggedFiles()
+		}
+		m.err = msg.Err
+		m.querying = false
+		return m, tea.Batch(cmds...)
+
+	case FuzzySearchResultsMsg: // Message type for fuzzy matcher results
+		if !m.finishSearch(msg.Gen) {
+			return m, tea.Batch(cmds...)
 		}
-	case FuzzySearchResultsMsg: // Message type for fzf --filter results
-		log.Printf("SearchModel: FuzzySearchResultsMsg received. %d paths matched.", len(msg))
-		m.querying = false // Fuzzy search is complete
 
 		// Step 1: Initialize displayed results with all currently tagged files.
+		// They form the pinned section at the top, in the order they were tagged.
 		// Use a map to efficiently track paths in newCombinedResults and avoid duplicates.
-		newCombinedResults := make([]FileItem, 0, len(m.allTaggedFiles)+len(msg))
+		newCombinedResults := make([]FileItem, 0, len(m.allTaggedFiles)+len(msg.Matches))
 		seenPathsInCombined := make(map[string]bool)
 
 		for _, item := range m.allTaggedFiles {
+			item.MatchedIndexes = nil // Only set if this query matches the file, below
 			newCombinedResults = append(newCombinedResults, item)
 			seenPathsInCombined[item.Path] = true
 		}
-		log.Printf("SearchModel: Initial combined results with %d allTaggedFiles.", len(newCombinedResults))
 
-		// Step 2: Add new fuzzy search results if not already present (i.e., not a tagged file)
-		contentLoadCmds := make([]tea.Cmd, 0)
-		for _, p := range msg {
+		// Step 2: Add new fuzzy search results if not already present (i.e., not a tagged file).
+		// Their content is not loaded here: it is only needed once a file is tagged.
+		pinned := len(newCombinedResults)
+		for rank, match := range msg.Matches {
+			p := match.Str
 			if !seenPathsInCombined[p] {
-				fileItem := FileItem{Path: p, Tagged: false} // Newly found, untagged
+				fileItem := FileItem{Path: p, Tagged: false, MatchedIndexes: match.MatchedIndexes, Rank: rank, Untracked: m.isUntracked(p)} // Newly found, untagged
 				newCombinedResults = append(newCombinedResults, fileItem)
-				contentLoadCmds = append(contentLoadCmds, m.loadFileContentCmd(p)) // Schedule content load
-				seenPathsInCombined[p] = true                                      // Mark as seen
-				log.Printf("SearchModel: Added new fzf result: %s, scheduling content load.", p)
+				seenPathsInCombined[p] = true // Mark as seen
 			} else {
-				// If a file from fzf results is already tagged, ensure its Tagged status is true in m.results
+				// If a file from the fuzzy results is already tagged, ensure its Tagged status is true in m.results
 				// This might be redundant if the initial population from m.allTaggedFiles already set it correctly,
 				// but it acts as a safeguard.
 				for i := range newCombinedResults {
 					if newCombinedResults[i].Path == p {
 						newCombinedResults[i].Tagged = true // Ensure tagged status is true in displayed results
+						newCombinedResults[i].MatchedIndexes = match.MatchedIndexes
 						break
 					}
 				}
-				log.Printf("SearchModel: Skipping fzf result %s (already in combined results, likely tagged).", p)
 			}
 		}
 
-		// Step 3: Sort the combined list by path for consistent display
-		sort.Slice(newCombinedResults, func(i, j int) bool {
-			return newCombinedResults[i].Path < newCombinedResults[j].Path
-		})
-
+		// Step 3: The matches arrive ranked best-first. Keep that order unless
+		// the user picked another sort order; the pinned section is left as is.
+		m.clearSelection()
 		m.results = newCombinedResults // Update the displayed results list
+		m.pinned = pinned
+		m.sortResults()
 
 		if len(m.results) == 0 && m.textInput.Value() != "" {
-			m.err = fmt.Errorf("no fuzzy matches found for '%s'", m.textInput.Value())
-			log.Printf("SearchModel: No fuzzy matches found for query: '%s'.", m.textInput.Value())
+			m.err = fmt.Errorf("no %s matches found for '%s'", m.match, m.textInput.Value())
 		} else {
 			m.err = nil
 		}
@@ -501,33 +1266,107 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else if m.cursor < 0 {
 				m.cursor = 0
 			}
-			m.resultsViewport.SetYOffset(m.cursor) // Scroll viewport to current cursor
+			m.ensureCursorVisible() // Scroll viewport to current cursor
 		} else {
 			m.cursor = 0
 		}
-		log.Printf("SearchModel: Updated results with %d combined files. Initiating content loads for new files.", len(m.results))
-		cmds = append(cmds, tea.Batch(contentLoadCmds...))
+		m.logger.Debug("Results updated", "gen", msg.Gen, "results", len(m.results), "pinned", m.pinned)
 		cmds = append(cmds, func() tea.Msg {
 			return TaggedFilesMsg(m.GetTaggedFiles()) // Ensure App model gets updated list
 		})
 		return m, tea.Batch(cmds...)
 
+	case ContentSearchResultsMsg: // ripgrep hits for content search mode
+		if !m.finishSearch(msg.Gen) {
+			// A newer query (or a mode switch) superseded this search.
+			return m, tea.Batch(cmds...)
+		}
+
+		taggedPaths := make(map[string]bool, len(m.allTaggedFiles))
+		for _, item := range m.allTaggedFiles {
+			taggedPaths[item.Path] = true
+		}
+
+		hits := msg.Matches
+		if len(hits) > maxContentResults {
+			m.logger.Debug("Truncating content matches", "matches", len(hits), "limit", maxContentResults)
+			hits = hits[:maxContentResults]
+		}
+		newResults := make([]FileItem, 0, len(hits))
+		for i := range hits {
+			match := hits[i] // Copy so each FileItem points at its own match
+			newResults = append(newResults, FileItem{
+				Path:          match.File,
+				Tagged:        taggedPaths[match.File],
+				OriginalMatch: &match,
+				Rank:          i, // ripgrep's own order: by file, then line
+				Untracked:     m.isUntracked(match.File),
+			})
+		}
+		m.clearSelection()
+		m.results = newResults
+		m.pinned = 0 // Hits for tagged files are marked in place rather than pinned
+		m.sortResults()
+
+		if len(m.results) == 0 {
+			m.err = fmt.Errorf("no content matches found for '%s'", m.textInput.Value())
+		} else {
+			m.err = nil
+		}
+		m.cursor = 0
+		m.resultsViewport.GotoTop()
+		return m, tea.Batch(cmds...)
+
+	case SymbolSearchResultsMsg: // Ranked declarations for symbol search mode
+		if !m.finishSearch(msg.Gen) {
+			return m, tea.Batch(cmds...)
+		}
+		newResults := make([]FileItem, 0, len(msg.Matches))
+		for i := range msg.Matches {
+			match := msg.Matches[i] // Copy so each FileItem points at its own symbol
+			item := FileItem{
+				Path:           match.Symbol.Path,
+				Symbol:         &match.Symbol,
+				MatchedIndexes: match.MatchedIndexes,
+				Rank:           i,
+				Untracked:      m.isUntracked(match.Symbol.Path),
+			}
+			item.Tagged = m.symbolTagged(item)
+			newResults = append(newResults, item)
+		}
+		m.clearSelection()
+		m.results = newResults
+		m.pinned = 0 // Like content hits, tagged declarations are marked in place
+		m.sortResults()
+
+		if len(m.results) == 0 {
+			m.err = fmt.Errorf("no symbols found for '%s'", m.textInput.Value())
+		} else {
+			m.err = nil
+		}
+		m.cursor = 0
+		m.resultsViewport.GotoTop()
+		return m, tea.Batch(cmds...)
+
 	case FuzzySearchErrorMsg:
-		log.Printf("SearchModel: FuzzySearchErrorMsg received: %v", msg.Err)
+		m.logger.Warn("Fuzzy search failed", "gen", msg.Gen, logging.KeyError, msg.Err)
+		if !m.finishSearch(msg.Gen) {
+			return m, tea.Batch(cmds...)
+		}
 		// On error, show only tagged files if any, otherwise clear results.
-		m.results = m.GetTaggedFiles() // Display existing tagged files
+		m.showTaggedFiles() // Display existing tagged files
 		m.err = msg.Err
-		m.querying = false
 		m.resultsViewport.SetContent("Error: " + msg.Err.Error()) // Show error in viewport
-		m.cursor = 0
 		cmds = append(cmds, func() tea.Msg { return TaggedFilesMsg(m.GetTaggedFiles()) })
 		return m, tea.Batch(cmds...)
 
 	case SearchResultsMsg: // This message type was for previous direct ripgrep output, now mostly unused.
-		log.Printf("SearchModel: SearchResultsMsg received (likely from a stale command, not used in fuzzy flow).")
+		m.logger.Debug("Received deprecated SearchResultsMsg")
 		// This case is largely deprecated as fuzzy search uses FuzzySearchResultsMsg now.
 		// If it ever gets triggered, handle it by replacing results and updating tagged.
+		m.clearSelection()
 		m.results = msg
+		m.pinned = 0
 		m.querying = false
 		m.cursor = 0
 		if len(m.results) == 0 && m.textInput.Value() != "" {
@@ -540,21 +1379,22 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return TaggedFilesMsg(m.GetTaggedFiles())
 		})
 	case SearchErrorMsg: // This message type is for errors from the underlying ripgrep content search
-		log.Printf("SearchModel: SearchErrorMsg received: %v", msg.Err)
+		if !m.finishSearch(msg.Gen) {
+			break
+		}
 		// On error, show only tagged files if any, otherwise clear results.
-		m.results = m.GetTaggedFiles() // Display existing tagged files
+		m.showTaggedFiles() // Display existing tagged files
 		m.err = msg.Err
-		m.querying = false
 		m.resultsViewport.SetContent("Error: " + msg.Err.Error()) // Show error in viewport
 	case fileContentMsg:
-		log.Printf("SearchModel: fileContentMsg received for %s. Content length: %d", msg.Path, len(msg.Content))
-		// Update content for the file in both m.results (if present) and m.allTaggedFiles
-		// No need for 'foundInResults' here as its only purpose was to check if the file was in results,
-		// but the update logic now correctly updates m.results in-place if found.
+		m.finishLoad(msg.Path)
+		// Update content for the file in both m.results (if present) and m.allTaggedFiles.
+		// Content search can list several hits (and symbol search several symbols)
+		// for the same file, so every result with the path gets the content.
 		for i := range m.results {
 			if m.results[i].Path == msg.Path {
 				m.results[i].Content = msg.Content
-				break
+				m.results[i].Omitted = msg.Omitted
 			}
 		}
 
@@ -562,7 +1402,7 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		for i := range m.allTaggedFiles {
 			if m.allTaggedFiles[i].Path == msg.Path {
 				m.allTaggedFiles[i].Content = msg.Content
-				log.Printf("SearchModel: Content field updated for %s in allTaggedFiles. New length: %d", msg.Path, len(m.allTaggedFiles[i].Content))
+				m.allTaggedFiles[i].Omitted = msg.Omitted
 				break
 			}
 		}
@@ -570,16 +1410,15 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, func() tea.Msg {
 			return TaggedFilesMsg(m.GetTaggedFiles()) // Ensure App model gets updated list with content
 		})
-		log.Printf("SearchModel: Sent TaggedFilesMsg after fileContentMsg (ensuring content is passed).")
 
 	case fileContentErrorMsg:
-		log.Printf("SearchModel: fileContentErrorMsg received for %s: %v", msg.Path, msg.Err)
+		m.finishLoad(msg.Path)
+		m.loadFailed[msg.Path] = true
 		// The error is logged. Update the content field to reflect the error if needed
 		// in m.results and m.allTaggedFiles to prevent re-attempts for this session.
-		for i := range m.results {
+		for i := range m.results { // Every hit for the file, as above
 			if m.results[i].Path == msg.Path {
 				m.results[i].Content = fmt.Sprintf("Error loading content: %v", msg.Err)
-				break
 			}
 		}
 		for i := range m.allTaggedFiles {
@@ -605,49 +1444,238 @@ func (m *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// searchTitle returns the heading for the search input, which names the active
+// search mode and, for file and symbol search, the match mode.
+func (m *SearchModel) searchTitle() string {
+	title := lipgloss.NewStyle().Bold(true)
+	switch m.mode {
+	case ContentSearchMode:
+		return title.Render("🔍 Search File Contents") + m.scopeBadge()
+	case SymbolSearchMode:
+		return title.Render("🔍 Search Symbols") + m.matchBadge() + m.scopeBadge()
+	}
+	return title.Render("🔍 Search Files") + m.matchBadge() + m.scopeBadge()
+}
+
+// matchBadge renders the active match mode for the search title.
+func (m *SearchModel) matchBadge() string {
+	return "  " + lipgloss.NewStyle().Foreground(styles.AccentColor).Bold(true).Render("["+m.match.String()+"]") +
+		lipgloss.NewStyle().Foreground(styles.MutedColor).Render(" Alt+M")
+}
+
+// matchLabel returns the match mode to record with a query in the history:
+// empty for fuzzy matching and for content search, which always uses ripgrep.
+func (m *SearchModel) matchLabel() string {
+	if m.mode == ContentSearchMode || m.match == search.MatchFuzzy {
+		return ""
+	}
+	return m.match.String()
+}
+
+// placeholder returns the search input placeholder for the active mode.
+func (m *SearchModel) placeholder() string {
+	switch m.mode {
+	case ContentSearchMode:
+		return "Type a pattern to search file contents, e.g. 'TODO ext:go'... (Ctrl+T: search symbols)"
+	case SymbolSearchMode:
+		return "Type to search functions, types, methods and constants, e.g. 'NewServer ext:go'... (Ctrl+T: search file names)"
+	}
+	return "Type to fuzzy search for files, e.g. 'model path:internal/ test:no'... (Ctrl+T: search contents)"
+}
+
+// resultLabel returns the text shown for a result row: the path for file matches
+// (plus the tagged line ranges, if any), file:line:col followed by the matched
+// line for content matches, or the name, kind and location of a symbol. The second
+// return value holds the rune offsets within the label that should be highlighted.
+func resultLabel(item FileItem) (string, []int) {
+	if item.Symbol != nil {
+		// The name comes first, so the matched offsets apply unchanged.
+		sym := item.Symbol
+		return fmt.Sprintf("%s  %s  %s:%s", sym.Name, sym.Kind, sym.Path, sym.Lines), item.MatchedIndexes
+	}
+	if item.OriginalMatch == nil {
+		if len(item.Ranges) > 0 {
+			return fmt.Sprintf("%s (%s)", item.Path, content.FormatRanges(item.Ranges)), item.MatchedIndexes
+		}
+		return item.Path, item.MatchedIndexes
+	}
+
+	match := item.OriginalMatch
+	prefix := fmt.Sprintf("%s:%d:%d  ", match.File, match.Line, match.Col)
+	text := strings.TrimLeft(match.Text, " \t")
+	trimmed := len(match.Text) - len(text)
+	text = strings.TrimRight(text, " \t")
+
+	// Col is a 1-based byte offset into the untrimmed line; translate the
+	// matched span into rune offsets within the label.
+	var positions []int
+	start := match.Col - 1 - trimmed
+	end := start + len(match.Match)
+	if match.Match != match.Text && start >= 0 && end <= len(text) {
+		offset := utf8.RuneCountInString(prefix) + utf8.RuneCountInString(text[:start])
+		for i := range []rune(text[start:end]) {
+			positions = append(positions, offset+i)
+		}
+	}
+	return prefix + text, positions
+}
+
+// renderHighlighted renders text with base, drawing the runes at the given
+// offsets with the match highlight style on top of it.
+func renderHighlighted(text string, positions []int, base lipgloss.Style) string {
+	if len(positions) == 0 {
+		return base.Render(text)
+	}
+
+	highlight := styles.MatchHighlightStyle.Inherit(base)
+	hit := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		hit[p] = true
+	}
+
+	// Render runs of equally styled runes together to keep the escape codes short.
+	var out strings.Builder
+	var run []rune
+	runHit := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if runHit {
+			out.WriteString(highlight.Render(string(run)))
+		} else {
+			out.WriteString(base.Render(string(run)))
+		}
+		run = run[:0]
+	}
+	for i, r := range []rune(text) {
+		if hit[i] != runHit {
+			flush()
+			runHit = hit[i]
+		}
+		run = append(run, r)
+	}
+	flush()
+	return out.String()
+}
+
+// helpView renders the key help under the search input, wrapped to the tab's
+// width once it is known.
+func (m *SearchModel) helpView() string {
+	style := styles.HelpStyle
+	if m.width > 0 {
+		style = style.Width(m.width)
+	}
+	return style.Render("Type to search (auto-updates) • Filters: ext: path: -path: size:<20k changed:7d test:no • Ctrl+T: Files/Content/Symbols mode • Alt+M: Fuzzy/Exact/Regex/Glob • Ctrl+G: Git scope • Alt+T: Tag scope • Ctrl+S: Sort • Ctrl+N/Ctrl+P: Navigate • Ctrl+A: Tag/Untag • Ctrl+O: Tag directory/glob • Ctrl+X: Select range • Alt+A: Tag all • Alt+U: Untag all • Alt+I: Invert • Ctrl+R: History • Alt+S: Save query • Alt+P: Preview • Alt+J/Alt+K: Scroll preview • Esc: Clear Search • Ctrl+Q: Quit • j/k: Scroll Line • Ctrl+U/Ctrl+D: Scroll Half Page • PageUp/PageDown: Scroll Full Page • Mouse Wheel")
+}
+
+// resize lays the tab out for its current size: the results take the full
+// width, or share it with the preview pane when that is shown, and both get
+// whatever height the search input, help and status lines leave.
+func (m *SearchModel) resize() {
+	if m.width == 0 {
+		return // No WindowSizeMsg yet
+	}
+
+	// Fixed height in the search tab:
+	// Search title, spacer, search input, spacer: 4 lines
+	// Help text: as many lines as it wraps to
+	// Status section: 1 line
+	// Spacer, results title, spacer: 3 lines
+	fixedHeight := 4 + lipgloss.Height(m.helpView()) + 1 + 3
+	resultsHeight := max(m.height-fixedHeight, 5) // Ensure minimum height for results viewport
+
+	listWidth := m.width
+	if m.previewVisible() {
+		listWidth = m.width * 45 / 100
+		// The pane's left border and padding take two columns.
+		m.preview.viewport.Width = max(m.width-listWidth-2, 0)
+		m.preview.viewport.Height = resultsHeight
+		m.preview.rendered = "" // Scroll the focus line back into view
+	}
+
+	// The input's prompt and cursor take three columns of their own.
+	m.textInput.Width = max(m.width-3, 1)
+	m.resultsViewport.Width = max(listWidth-1, 0) // One column of gap before the preview
+	m.resultsViewport.Height = resultsHeight
+	m.logger.Debug("Resized results viewport", "width", m.resultsViewport.Width, "height", m.resultsViewport.Height, "preview", m.previewVisible())
+}
+
 // View renders the search interface, including input, results, and optional preview.
 func (m *SearchModel) View() string {
 	// Search input section
 	searchSection := lipgloss.JoinVertical(
 		lipgloss.Left,
-		lipgloss.NewStyle().Bold(true).Render("🔍 Fuzzy Search Files"),
+		m.searchTitle(),
 		"",
 		m.textInput.View(),
 		"",
-		styles.HelpStyle.Render("Type to fuzzy search (auto-updates) • Ctrl+N/Ctrl+P: Navigate • Ctrl+A: Tag/Untag • Esc: Clear Search • Ctrl+Q: Quit • j/k: Scroll Line • Ctrl+U/Ctrl+D: Scroll Half Page • PageUp/PageDown: Scroll Full Page • Mouse Wheel"),
+		m.helpView(),
 	)
 
-	// Section for displaying any errors or fzf status.
+	// Section for displaying any errors or search status.
 	var statusSection string
-	if m.querying {
+	if m.selecting {
+		lo, hi := m.selectionBounds()
+		statusSection = lipgloss.NewStyle().
+			Foreground(styles.AccentColor).
+			Padding(0, 1).
+			Render(fmt.Sprintf("Selecting %d results • Ctrl+A: Tag/Untag selection • Alt+U: Untag • Alt+I: Invert • Ctrl+X/Esc: Cancel", hi-lo+1))
+	} else if m.querying {
 		statusSection = lipgloss.NewStyle().
 			Foreground(styles.MutedColor).
 			Padding(0, 1).
-			Render("Fuzzy searching and loading content...")
+			Render(fmt.Sprintf("Searching %s...", strings.ToLower(m.mode.String())))
 	} else if m.err != nil {
 		statusSection = lipgloss.NewStyle().
 			Foreground(styles.ErrorColor).
 			Padding(0, 1).
 			Render(fmt.Sprintf("Error: %s", m.err.Error()))
+	} else if m.loadTotal > 0 {
+		statusSection = lipgloss.NewStyle().
+			Foreground(styles.MutedColor).
+			Padding(0, 1).
+			Render(fmt.Sprintf("Loading file contents... %d/%d", m.loadDone, m.loadTotal))
 	} else if len(m.results) == 0 && m.textInput.Value() != "" {
 		statusSection = lipgloss.NewStyle().
 			Foreground(styles.MutedColor).
 			Padding(0, 1).
-			Render("No fuzzy matches found for your query.")
+			Render("No matches found for your query.")
+	} else if len(m.results) > m.pinned {
+		statusSection = lipgloss.NewStyle().
+			Foreground(styles.MutedColor).
+			Padding(0, 1).
+			Render(fmt.Sprintf("Found %d matches • sorted by %s", len(m.results)-m.pinned, m.sortOrder))
 	} else if len(m.results) > 0 {
 		statusSection = lipgloss.NewStyle().
 			Foreground(styles.MutedColor).
 			Padding(0, 1).
-			Render(fmt.Sprintf("Found %d fuzzy matches.", len(m.results)))
+			Render(fmt.Sprintf("Showing %d tagged files.", len(m.results)))
 	}
 
 	// Results section
 	var resultsSection string
-	resultsTitle := lipgloss.NewStyle().Bold(true).Render("📄 Fuzzy Search Results")
+	resultsTitle := lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("📄 %s Search Results", m.mode))
+	if m.indexReady {
+		// Read live rather than from IndexReadyMsg: the watcher rebuilds the index when ignore files change.
+		resultsTitle += lipgloss.NewStyle().Foreground(styles.MutedColor).Render(
+			fmt.Sprintf("  %d files indexed • %d ignored", m.index.Len(), m.index.Ignored()))
+	}
 
 	var resultsContentBuilder strings.Builder
 	if len(m.results) > 0 {
+		sectionStyle := lipgloss.NewStyle().Foreground(styles.MutedColor).Bold(true)
 		for i, fileItem := range m.results {
+			// Section headers; keep in sync with resultLine.
+			if m.pinned > 0 && i == 0 {
+				resultsContentBuilder.WriteString(sectionStyle.Render(fmt.Sprintf("📌 Tagged (%d)", m.pinned)))
+				resultsContentBuilder.WriteString("\n")
+			}
+			if m.pinned > 0 && i == m.pinned {
+				resultsContentBuilder.WriteString(sectionStyle.Render(fmt.Sprintf("🔎 Matches (%d)", len(m.results)-m.pinned)))
+				resultsContentBuilder.WriteString("\n")
+			}
+
 			var style lipgloss.Style
 			cursor := "  "
 
@@ -671,7 +1699,17 @@ func (m *SearchModel) View() string {
 			}
 
 			// Render the line with its style and append to builder
-			resultsContentBuilder.WriteString(style.Render(cursor + tag + fileItem.Path))
+			label, positions := resultLabel(fileItem)
+			if m.inSelection(i) && i != m.cursor {
+				// A bar marks the rest of the selected range.
+				resultsContentBuilder.WriteString(styles.SelectionMarkStyle.Render("┃ ") + style.Render(tag))
+			} else {
+				resultsContentBuilder.WriteString(style.Render(cursor + tag))
+			}
+			resultsContentBuilder.WriteString(renderHighlighted(label, positions, style))
+			if fileItem.Untracked {
+				resultsContentBuilder.WriteString(" " + styles.NewBadgeStyle.Render("new"))
+			}
 			resultsContentBuilder.WriteString("\n")
 		}
 	} else if m.textInput.Value() == "" && !m.querying && m.err == nil {
@@ -695,6 +1733,21 @@ func (m *SearchModel) View() string {
 	)
 
 	// Combine all sections for the main view
+	if m.group.active {
+		// The group dialog takes the place of the status line while it is open.
+		statusSection = m.groupPromptView()
+	} else if m.picker.active {
+		// So does the history picker.
+		statusSection = m.historyPickerView()
+	}
+	if m.previewVisible() {
+		// Long result rows and titles are cut rather than pushing the preview aside.
+		resultsSection = lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			lipgloss.NewStyle().Width(m.resultsViewport.Width+1).MaxWidth(m.resultsViewport.Width+1).Render(resultsSection),
+			m.previewView(),
+		)
+	}
 	mainView := lipgloss.JoinVertical(
 		lipgloss.Left,
 		searchSection,
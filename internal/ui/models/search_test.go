@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"prompty/internal/content"
+	"prompty/internal/logging"
+	"prompty/internal/symbols"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUntagSymbolOfWholeFile(t *testing.T) {
+	src := "package a\n\nfunc One() {}\n\nfunc Two() {}\n"
+	one := &symbols.Symbol{Name: "One", Kind: symbols.Func, Path: "a.go", Lines: content.LineRange{Start: 3, End: 3}}
+
+	tests := []struct {
+		name    string
+		loaded  tea.Msg // The message the load of a.go ends with
+		wantErr bool
+		want    []content.LineRange
+	}{
+		{
+			name:   "text",
+			loaded: fileContentMsg{Path: "a.go", Content: src},
+			want:   []content.LineRange{{Start: 1, End: 2}, {Start: 4, End: 5}},
+		},
+		{
+			// Its lines aren't known, so the untag is refused rather than guessed.
+			name:    "omitted",
+			loaded:  fileContentMsg{Path: "a.go", Omitted: "binary file, 2.1 MB"},
+			wantErr: true,
+		},
+		{
+			// The error text in Content isn't the file's.
+			name:    "load error",
+			loaded:  fileContentErrorMsg{Path: "a.go", Err: errors.New("permission denied")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, map[string]string{"a.go": src})
+			send(app, buildIndexCmd(app.searchModel.index, logging.Discard())())
+			m := app.searchModel
+			m.tagFile(FileItem{Path: "a.go"})
+
+			item := FileItem{Path: "a.go", Symbol: one}
+			if _, err := m.setSymbolTagged(item, false); err == nil {
+				t.Error("untagging a symbol while the file loads succeeded, want an error")
+			}
+			send(app, tt.loaded)
+
+			_, err := m.setSymbolTagged(item, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setSymbolTagged() error = %v, want error %v", err, tt.wantErr)
+			}
+			tagged := m.GetTaggedFiles()
+			if len(tagged) != 1 || !slices.Equal(tagged[0].Ranges, tt.want) {
+				t.Errorf("tagged files = %+v, want a.go with ranges %v", tagged, tt.want)
+			}
+		})
+	}
+}
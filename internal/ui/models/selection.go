@@ -0,0 +1,122 @@
+package models
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Bulk tagging in the Search tab. Ctrl+X starts a range selection at the
+// cursor; moving the cursor extends it. While a range is selected, Ctrl+A,
+// Alt+U and Alt+I act on it instead of the single highlighted result. Without
+// a selection, Alt+A tags, Alt+U untags and Alt+I inverts every result listed.
+
+// toggleSelection starts a range selection anchored at the cursor, or drops
+// the current one.
+func (m *SearchModel) toggleSelection() {
+	if m.selecting || len(m.results) == 0 {
+		m.clearSelection()
+		return
+	}
+	m.selecting = true
+	m.anchor = m.cursor
+	m.logger.Debug("Started range selection", "anchor", m.anchor)
+}
+
+// clearSelection ends range selection mode. It is called whenever the results
+// are replaced or re-ordered, since the selected indexes would no longer mean
+// the same rows.
+func (m *SearchModel) clearSelection() {
+	m.selecting = false
+	m.anchor = 0
+}
+
+// selectionBounds returns the first and last index of the selected range.
+func (m *SearchModel) selectionBounds() (int, int) {
+	lo, hi := min(m.anchor, m.cursor), max(m.anchor, m.cursor)
+	return max(lo, 0), min(hi, len(m.results)-1)
+}
+
+// inSelection reports whether result i is part of the selected range.
+func (m *SearchModel) inSelection(i int) bool {
+	if !m.selecting {
+		return false
+	}
+	lo, hi := m.selectionBounds()
+	return i >= lo && i <= hi
+}
+
+// bulkTargets returns the indexes the bulk actions apply to: the selected range
+// if there is one, otherwise every result.
+func (m *SearchModel) bulkTargets() []int {
+	lo, hi := 0, len(m.results)-1
+	if m.selecting {
+		lo, hi = m.selectionBounds()
+	}
+	targets := make([]int, 0, max(hi-lo+1, 0))
+	for i := lo; i <= hi; i++ {
+		targets = append(targets, i)
+	}
+	return targets
+}
+
+// tagSelection tags every result in the selected range, or untags them all if
+// every one of them is tagged already, and ends the selection.
+func (m *SearchModel) tagSelection() tea.Cmd {
+	targets := m.bulkTargets()
+	allTagged := true
+	for _, i := range targets {
+		allTagged = allTagged && m.results[i].Tagged
+	}
+	return m.setTagged(targets, func(int) bool { return !allTagged })
+}
+
+// setTagged tags or untags each of the results at targets, as decided by
+// tagged, then ends the selection and tells the App about the new tags.
+// Results whose files vanished from the project are skipped and reported.
+func (m *SearchModel) setTagged(targets []int, tagged func(i int) bool) tea.Cmd {
+	var cmds []tea.Cmd
+	changed, failed := 0, 0
+	// Decide for every result first: tagging one result can change the mark of
+	// others (content hits in the same file, overlapping declarations).
+	want := make([]bool, len(targets))
+	for j, i := range targets {
+		want[j] = tagged(i)
+	}
+	for j, i := range targets {
+		if m.results[i].Tagged == want[j] {
+			continue
+		}
+		loadCmd, err := m.setResultTagged(i, want[j])
+		if err != nil {
+			m.err = err
+			failed++
+			continue
+		}
+		cmds = append(cmds, loadCmd)
+		changed++
+	}
+	m.clearSelection()
+	m.logger.Info("Bulk tagging", "results", len(targets), "changed", changed, "failed", failed)
+	if changed == 0 {
+		return tea.Batch(cmds...)
+	}
+	cmds = append(cmds, saveFrecencyCmd(m.frecency, m.logger), m.rememberQuery())
+	cmds = append(cmds, func() tea.Msg { return TaggedFilesMsg(m.GetTaggedFiles()) })
+	return tea.Batch(cmds...)
+}
+
+// tagAll tags every result in the list (Alt+A).
+func (m *SearchModel) tagAll() tea.Cmd {
+	m.clearSelection()
+	return m.setTagged(m.bulkTargets(), func(int) bool { return true })
+}
+
+// untagTargets untags the selected range, or every result (Alt+U).
+func (m *SearchModel) untagTargets() tea.Cmd {
+	return m.setTagged(m.bulkTargets(), func(int) bool { return false })
+}
+
+// invertTargets flips the tag of each result in the selected range, or of
+// every result (Alt+I).
+func (m *SearchModel) invertTargets() tea.Cmd {
+	return m.setTagged(m.bulkTargets(), func(i int) bool { return !m.results[i].Tagged })
+}
@@ -0,0 +1,433 @@
+package models
+
+import (
+	"fmt"
+	"prompty/internal/content"
+	"prompty/internal/ui/styles"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The file viewer of the Browse tab: the preview of the highlighted tagged file,
+// with line numbers, in a viewport sized from the terminal. "/" searches the
+// file (smart case, like the fuzzy search), n and N jump to the next and
+// previous match, ":" jumps to a line, and the usual viewport keys scroll.
+// "v" selects lines to tag; see lines.go.
+
+// viewerPrompt is what the input line under the viewer is asking for, if anything.
+type viewerPrompt int
+
+const (
+	viewerNoPrompt     viewerPrompt = iota // 0: Keys scroll the viewer
+	viewerSearchPrompt                     // 1: Text to search the file for ("/")
+	viewerGotoPrompt                       // 2: Line number to jump to (":")
+)
+
+// viewerMatch is one occurrence of the search text in the viewed file.
+type viewerMatch struct {
+	Line  int // 1-based line number
+	Start int // Rune offset of the first matched character within the line
+	End   int // Rune offset just past the last matched character
+}
+
+// fileViewer shows one file in a scrollable viewport. The lines of the file's
+// tagged ranges are marked in the gutter.
+type fileViewer struct {
+	viewport  viewport.Model
+	input     textinput.Model     // Reads the search text or line number
+	prompt    viewerPrompt        // What input is reading, if it is open
+	path      string              // File shown; empty when the viewer is closed
+	lines     []string            // Content of path as split by previewLines; nil when omitted
+	omitted   string              // Why the content was left out, if it was
+	ranges    []content.LineRange // Tagged ranges of path; empty when the whole file is tagged
+	plain     []string            // lines rendered with their gutter, before highlighting; see render
+	query     string              // Text searched for last
+	matches   []viewerMatch       // Occurrences of query, in file order
+	current   int                 // Index into matches of the one jumped to last; -1 for none
+	focus     int                 // Line jumped to last, highlighted; 0 for none
+	selecting bool                // Whether lines are being selected (v); focus is the moving end
+	anchor    int                 // Line the selection started at
+	notice    string              // Feedback on the last search or jump, e.g. that it wrapped
+	err       error               // Why the last search or jump failed, if it did
+}
+
+// newFileViewer creates a closed viewer. Until the first WindowSizeMsg it is as
+// big as the fixed preview box it replaces.
+func newFileViewer() fileViewer {
+	vp := viewport.New(60, 15)
+	vp.MouseWheelEnabled = true
+	input := textinput.New()
+	input.Width = 57
+	return fileViewer{viewport: vp, input: input, current: -1}
+}
+
+// setSize resizes the viewport to width by height cells of file content.
+func (v *fileViewer) setSize(width, height int) {
+	v.viewport.Width = max(width, 10)
+	v.viewport.Height = max(height, 3)
+	v.input.Width = max(width-3, 1) // Prompt and cursor
+	if v.focus > 0 {
+		v.scrollTo(v.focus)
+	}
+}
+
+// open shows file in the viewer. Opening the file already shown refreshes its
+// content and tagged ranges but keeps the scroll position and the search;
+// opening another one starts at its top.
+func (v *fileViewer) open(file FileItem) {
+	if file.Path != v.path {
+		v.close()
+		v.path = file.Path
+	}
+	v.omitted, v.ranges, v.lines = file.Omitted, file.Ranges, nil
+	if file.Omitted == "" {
+		v.lines = previewLines(file.Content)
+	}
+	v.plain = nil
+	if v.query != "" {
+		v.findMatches()
+	}
+	v.render()
+}
+
+// close empties the viewer and forgets its search.
+func (v *fileViewer) close() {
+	v.closePrompt()
+	v.path, v.lines, v.omitted, v.ranges, v.plain = "", nil, "", nil, nil
+	v.query, v.matches, v.current, v.focus = "", nil, -1, 0
+	v.selecting, v.anchor = false, 0
+	v.notice, v.err = "", nil
+	v.viewport.SetContent("")
+	v.viewport.GotoTop()
+}
+
+// typing reports whether the viewer's input line is open, so keys are text.
+func (v *fileViewer) typing() bool {
+	return v.prompt != viewerNoPrompt
+}
+
+// openPrompt opens the input line for a search or a line number.
+func (v *fileViewer) openPrompt(prompt viewerPrompt) tea.Cmd {
+	v.prompt = prompt
+	v.notice, v.err = "", nil
+	v.input.SetValue("")
+	if prompt == viewerSearchPrompt {
+		v.input.Prompt = "/"
+		v.input.Placeholder = "search this file (Enter alone repeats the last search)"
+	} else {
+		v.input.Prompt = ":"
+		v.input.Placeholder = fmt.Sprintf("line number (1–%d)", len(v.lines))
+	}
+	return v.input.Focus()
+}
+
+// closePrompt closes the input line.
+func (v *fileViewer) closePrompt() {
+	v.prompt = viewerNoPrompt
+	v.input.Blur()
+}
+
+// update handles a key pressed while the viewer is shown.
+func (v *fileViewer) update(msg tea.KeyMsg) tea.Cmd {
+	if v.prompt != viewerNoPrompt {
+		switch msg.Type {
+		case tea.KeyEsc:
+			v.closePrompt()
+		case tea.KeyEnter:
+			prompt, value := v.prompt, strings.TrimSpace(v.input.Value())
+			v.closePrompt()
+			if prompt == viewerSearchPrompt {
+				v.search(value)
+			} else {
+				v.gotoLine(value)
+			}
+		default:
+			var cmd tea.Cmd
+			v.input, cmd = v.input.Update(msg)
+			return cmd
+		}
+		return nil
+	}
+
+	if v.selecting && v.moveSelection(msg.String()) {
+		return nil
+	}
+	switch msg.String() {
+	case "v": // Start selecting lines, or tag the selected ones
+		if v.selecting {
+			return v.tagSelection()
+		}
+		v.startSelection()
+	case "x": // Take the selected lines, or the tagged range under the cursor, out of the prompt
+		return v.untagLines()
+	case "/":
+		return v.openPrompt(viewerSearchPrompt)
+	case ":":
+		return v.openPrompt(viewerGotoPrompt)
+	case "n":
+		v.nextMatch(1)
+	case "N":
+		v.nextMatch(-1)
+	case "g", "home":
+		v.viewport.GotoTop()
+	case "G", "end":
+		v.viewport.GotoBottom()
+	default:
+		var cmd tea.Cmd
+		v.viewport, cmd = v.viewport.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+// search finds every occurrence of query and jumps to the first one at or
+// below the top of the viewport. An empty query repeats the last search.
+func (v *fileViewer) search(query string) {
+	if query == "" {
+		v.nextMatch(1)
+		return
+	}
+	v.notice, v.err = "", nil
+	v.query = query
+	v.findMatches()
+	v.current = -1
+	if len(v.matches) == 0 {
+		v.focus = 0
+		v.render()
+		v.err = fmt.Errorf("no matches for %q", query)
+		return
+	}
+
+	// Like less, start from what is on screen rather than from the top.
+	top := v.viewport.YOffset + 1
+	next := 0
+	for i, match := range v.matches {
+		if match.Line >= top {
+			next = i
+			break
+		}
+	}
+	v.jumpToMatch(next)
+}
+
+// findMatches lists the occurrences of the search text in the file. The text
+// matches case-insensitively unless it has an upper-case letter.
+func (v *fileViewer) findMatches() {
+	v.matches = nil
+	query := []rune(v.query)
+	caseSensitive := strings.IndexFunc(v.query, unicode.IsUpper) >= 0
+	if !caseSensitive {
+		for i, r := range query {
+			query[i] = unicode.ToLower(r)
+		}
+	}
+	for i, line := range v.lines {
+		text := []rune(line)
+		for start := 0; start+len(query) <= len(text); {
+			if hasPrefixFold(text[start:], query, caseSensitive) {
+				v.matches = append(v.matches, viewerMatch{Line: i + 1, Start: start, End: start + len(query)})
+				start += len(query)
+			} else {
+				start++
+			}
+		}
+	}
+	if v.current >= len(v.matches) {
+		v.current = -1
+	}
+}
+
+// hasPrefixFold reports whether text starts with query, which is already lower
+// case unless the comparison is case-sensitive.
+func hasPrefixFold(text, query []rune, caseSensitive bool) bool {
+	for i, r := range query {
+		c := text[i]
+		if !caseSensitive {
+			c = unicode.ToLower(c)
+		}
+		if c != r {
+			return false
+		}
+	}
+	return true
+}
+
+// nextMatch jumps to the match after (dir 1) or before (dir -1) the current
+// one, wrapping around the ends of the file (n and N).
+func (v *fileViewer) nextMatch(dir int) {
+	v.notice, v.err = "", nil
+	switch {
+	case v.query == "":
+		v.err = fmt.Errorf("press / to search this file first")
+		return
+	case len(v.matches) == 0:
+		v.err = fmt.Errorf("no matches for %q", v.query)
+		return
+	}
+	next := v.current + dir
+	if v.current < 0 && dir < 0 {
+		next = len(v.matches) - 1
+	}
+	switch {
+	case next >= len(v.matches):
+		next = 0
+		v.notice = "search hit the bottom, continuing at the top"
+	case next < 0:
+		next = len(v.matches) - 1
+		v.notice = "search hit the top, continuing at the bottom"
+	}
+	v.jumpToMatch(next)
+}
+
+// jumpToMatch scrolls to match i and highlights it.
+func (v *fileViewer) jumpToMatch(i int) {
+	v.current = i
+	v.focus = v.matches[i].Line
+	v.render()
+	v.scrollTo(v.focus)
+}
+
+// gotoLine jumps to the line numbered value (":"). Numbers past the end go to
+// the last line.
+func (v *fileViewer) gotoLine(value string) {
+	v.notice, v.err = "", nil
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		v.err = fmt.Errorf("%q is not a line number", value)
+		return
+	}
+	if len(v.lines) == 0 {
+		v.err = fmt.Errorf("there are no lines to jump to")
+		return
+	}
+	v.focus = min(n, len(v.lines))
+	v.render()
+	v.scrollTo(v.focus)
+}
+
+// scrollTo scrolls so line sits a third of the way down the viewport, leaving
+// some context above it.
+func (v *fileViewer) scrollTo(line int) {
+	v.viewport.SetYOffset(max(line-1-v.viewport.Height/3, 0))
+}
+
+// render writes the file into the viewport: each line behind its number and a
+// gutter mark (a green "┃" for tagged ranges, an amber one for the selection,
+// "▶" for the line jumped to), with the search matches highlighted. The plain lines are kept between renders, so only
+// the focus line and the lines with matches are styled again, which keeps n and
+// N fast on big files.
+func (v *fileViewer) render() {
+	if v.omitted != "" {
+		v.viewport.SetContent(lipgloss.NewStyle().Foreground(styles.MutedColor).
+			Render(fmt.Sprintf("(%s; content not included in the prompt)", v.omitted)))
+		return
+	}
+
+	width := len(strconv.Itoa(len(v.lines)))
+	gutter := func(n int, focused bool) string {
+		if focused {
+			return styles.FocusLineStyle.Render(fmt.Sprintf("%*d", width, n)) +
+				lipgloss.NewStyle().Foreground(styles.AccentColor).Bold(true).Render("▶") + " "
+		}
+		number := styles.LineNumberStyle.Render(fmt.Sprintf("%*d", width, n))
+		switch {
+		case v.selecting && v.selection().Contains(content.LineRange{Start: n, End: n}):
+			return number + styles.SelectionMarkStyle.Render("┃") + " "
+		case content.Covers(v.ranges, content.LineRange{Start: n, End: n}):
+			return number + taggedLineMark + " "
+		}
+		return number + "  "
+	}
+
+	if v.plain == nil {
+		v.plain = make([]string, len(v.lines))
+		for i, line := range v.lines {
+			v.plain[i] = gutter(i+1, false) + line
+		}
+	}
+
+	hits := make(map[int][]int)
+	for _, match := range v.matches {
+		for p := match.Start; p < match.End; p++ {
+			hits[match.Line] = append(hits[match.Line], p)
+		}
+	}
+	out := make([]string, len(v.lines))
+	copy(out, v.plain)
+	for n, positions := range hits {
+		out[n-1] = gutter(n, false) + renderHighlighted(v.lines[n-1], positions, styles.NormalStyle)
+	}
+	if v.selecting {
+		// The plain lines don't know about the selection; mark it afresh.
+		sel := v.selection()
+		for n := sel.Start; n <= sel.End && n <= len(v.lines); n++ {
+			out[n-1] = gutter(n, false) + renderHighlighted(v.lines[n-1], hits[n], styles.SelectedStyle)
+		}
+	}
+	if v.focus > 0 && v.focus <= len(v.lines) {
+		out[v.focus-1] = gutter(v.focus, true) + renderHighlighted(v.lines[v.focus-1], hits[v.focus], styles.FocusLineStyle)
+	}
+	v.viewport.SetContent(strings.Join(out, "\n"))
+}
+
+// view renders the viewer: a title, the position in the file, the bordered
+// viewport and, below it, the input line or the feedback of the last command.
+func (v *fileViewer) view() string {
+	muted := lipgloss.NewStyle().Foreground(styles.MutedColor)
+	title := lipgloss.NewStyle().Bold(true).MaxWidth(v.viewport.Width + 2).Render("👁 Preview: " + v.path)
+
+	var position string
+	if total := len(v.lines); total > 0 && v.omitted == "" {
+		first := v.viewport.YOffset + 1
+		last := min(v.viewport.YOffset+v.viewport.Height, total)
+		position = fmt.Sprintf("Lines %d–%d of %d", first, last, total)
+		if v.query != "" {
+			if v.current >= 0 {
+				position += fmt.Sprintf(" • match %d/%d for /%s", v.current+1, len(v.matches), v.query)
+			} else {
+				position += fmt.Sprintf(" • %d matches for /%s", len(v.matches), v.query)
+			}
+		}
+		if len(v.ranges) > 0 {
+			position += " • tagged " + content.FormatRanges(v.ranges)
+		}
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.MutedColor).
+		Render(v.viewport.View())
+
+	var footer string
+	switch {
+	case v.prompt != viewerNoPrompt:
+		footer = v.input.View()
+	case v.selecting:
+		sel := v.selection()
+		footer = lipgloss.NewStyle().Foreground(styles.AccentColor).Render(
+			fmt.Sprintf("Selecting %s • j/k: Extend • v/Enter: Tag • x: Untag • Esc: Cancel", content.FormatRanges([]content.LineRange{sel})))
+	case v.err != nil:
+		footer = lipgloss.NewStyle().Foreground(styles.ErrorColor).Render("Error: " + v.err.Error())
+	case v.notice != "":
+		footer = lipgloss.NewStyle().Foreground(styles.AccentColor).Render(v.notice)
+	default:
+		footer = styles.HelpStyle.Render("/: Search • n/N: Next/Previous match • :: Go to line • v: Select lines • x: Untag range")
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		muted.MaxWidth(v.viewport.Width+2).Render(position),
+		box,
+		lipgloss.NewStyle().MaxWidth(v.viewport.Width+2).Render(footer),
+	)
+}
@@ -56,6 +56,33 @@ var (
 			Foreground(lipgloss.Color("#FFFFFF")). // White text
 			Bold(true)                             // Bold text
 
+	// NewBadgeStyle marks files git doesn't track yet.
+	NewBadgeStyle = lipgloss.NewStyle().
+			Foreground(SecondaryColor). // Green text
+			Bold(true)
+
+	// SelectionMarkStyle draws the bar in front of results inside a range selection.
+	SelectionMarkStyle = lipgloss.NewStyle().
+				Foreground(AccentColor). // Amber bar
+				Bold(true)
+
+	// MatchHighlightStyle marks the characters a search query matched inside a result.
+	// It is layered on top of the row's own style, so only the foreground changes.
+	MatchHighlightStyle = lipgloss.NewStyle().
+				Foreground(AccentColor). // Amber text for matched characters
+				Bold(true).
+				Underline(true)
+
+	// LineNumberStyle draws the line numbers in front of previewed file content.
+	LineNumberStyle = lipgloss.NewStyle().
+			Foreground(MutedColor) // Gray numbers
+
+	// FocusLineStyle marks the lines a preview jumped to, such as a content match.
+	FocusLineStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("#374151")). // Same dark gray as inactive tabs
+			Foreground(lipgloss.Color("#FFFFFF")). // White text
+			Bold(true)
+
 	// Tab styles for navigation.
 	ActiveTabStyle = lipgloss.NewStyle().
 			Bold(true).
@@ -0,0 +1,67 @@
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// appName is the directory name used under each XDG base directory.
+const appName = "prompty"
+
+// DataDir returns the directory for prompty's persistent data, such as
+// usage history: $XDG_DATA_HOME/prompty, defaulting to ~/.local/share/prompty.
+func DataDir() string {
+	return baseDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// StateDir returns the directory for state that may be lost without harm,
+// such as logs: $XDG_STATE_HOME/prompty, defaulting to ~/.local/state/prompty.
+func StateDir() string {
+	return baseDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// ConfigDir returns the directory for user configuration:
+// $XDG_CONFIG_HOME/prompty, defaulting to ~/.config/prompty.
+func ConfigDir() string {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// baseDir resolves an XDG base directory from env, falling back to the given
+// path under the user's home directory, and appends the application name.
+// Relative values of env are ignored, as the specification requires.
+func baseDir(env string, fallback ...string) string {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// Without a home directory there's nowhere sensible; use the temp dir.
+		home = os.TempDir()
+	}
+	return filepath.Join(append(append([]string{home}, fallback...), appName)...)
+}
+
+// WriteFile writes data to the file at path, creating its directory if needed.
+// The data goes to a temporary file in the same directory, named after
+// pattern (see os.CreateTemp), which then replaces path, so a crash or a
+// concurrent reader never sees a half-written file.
+func WriteFile(path, pattern string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
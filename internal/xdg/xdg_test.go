@@ -0,0 +1,65 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "/data")
+	t.Setenv("XDG_STATE_HOME", "relative/state") // Ignored: not absolute
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"DataDir", DataDir(), filepath.Join("/data", "prompty")},
+		{"StateDir", StateDir(), filepath.Join(home, ".local", "state", "prompty")},
+		{"ConfigDir", ConfigDir(), filepath.Join(home, ".config", "prompty")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s() = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "prompty")
+	path := filepath.Join(dir, "data.json")
+
+	for _, data := range []string{`{"v":1}`, `{"v":2}`} {
+		if err := WriteFile(path, ".data-*.json", []byte(data)); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != data {
+			t.Errorf("after WriteFile(%s) the file holds %q, %v", data, got, err)
+		}
+	}
+
+	// The temporary file is gone once it replaced the target.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("WriteFile() left %d files behind, want only data.json", len(entries))
+	}
+}
+
+func TestWriteFileError(t *testing.T) {
+	// A regular file where the directory should be.
+	parent := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(parent, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(filepath.Join(parent, "data.json"), ".data-*", []byte("x")); err == nil {
+		t.Error("WriteFile() under a regular file succeeded, want an error")
+	}
+}
@@ -1,39 +1,116 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"os" // Added: for file operations
+	"path/filepath"
+	"prompty/internal/content"
+	"prompty/internal/logging"
 	"prompty/internal/ui/models"
+	"prompty/internal/xdg"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	// Open or create a log file. If it already exists, it will be truncated.
-	// 0644 means read/write for owner, read-only for others.
-	f, err := os.OpenFile("prompty.log", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		log.Fatalf("Failed to open log file: %v", err)
+	opts := models.DefaultOptions()
+	flag.StringVar(&opts.Dir, "dir", "", "project directory to search (default: the current directory)")
+	flag.StringVar(&opts.Query, "query", "", "pre-fill the search input with this query")
+	flag.Func("tag", "tag this file at startup, relative to -dir; repeat to tag several files", func(value string) error {
+		opts.Tags = append(opts.Tags, value)
+		return nil
+	})
+	flag.Func("max-file-size", fmt.Sprintf("largest file whose content goes into the prompt, e.g. 512k or 2m; 0 for no limit (default %s)", content.FormatSize(opts.MaxFileSize)), func(value string) error {
+		size, err := content.ParseSize(value)
+		if err != nil {
+			return err
+		}
+		opts.MaxFileSize = size
+		return nil
+	})
+	flag.StringVar(&opts.Base, "base", "", "branch the Ctrl+G branch scope compares against (default: origin's default branch, main or master)")
+	flag.IntVar(&opts.RecentCommits, "recent-commits", opts.RecentCommits, "number of commits the Ctrl+G recent scope looks back")
+	logFile := flag.String("log-file", filepath.Join(xdg.StateDir(), "prompty.log"), "file to write logs to")
+	noLog := flag.Bool("no-log", false, "don't write a log file")
+	logLevel := flag.String("log-level", "info", "minimum level of log messages: debug, info, warn or error")
+	logRedact := flag.Bool("log-redact", false, "replace search queries and saved-query names in the log with their length, so it can be shared")
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %v\n", flag.Args())
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if opts.Dir != "" {
+		dir, err := filepath.Abs(opts.Dir)
+		if err == nil {
+			var info os.FileInfo
+			if info, err = os.Stat(dir); err == nil && !info.IsDir() {
+				err = fmt.Errorf("%s is not a directory", dir)
+			}
+		}
+		if err != nil {
+			log.Fatalf("Invalid -dir: %v", err)
+		}
+		opts.Dir = dir
 	}
-	defer f.Close() // Ensure the log file is closed when the program exits
 
-	// Set the log output to the file.
-	log.SetOutput(f)
-	// You can also set a log prefix and flags if desired:
-	// log.SetPrefix("prompty: ")
-	// log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	logger, closeLog, err := setupLogging(*logFile, *noLog, *logLevel, *logRedact)
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	defer closeLog() // Ensure the log file is closed when the program exits
+	opts.Logger = logger
 
-	log.Println("Application started, logging to prompty.log") // Initial log message to confirm setup
+	logger.Info("Application started", "log_file", *logFile, "dir", opts.Dir) // Initial log message to confirm setup
 
 	// Initialize the main app model
-	m := models.NewApp()
+	m := models.NewApp(opts)
+	defer m.Close() // Stop watching the file tree when the program exits
 
 	// Create the Bubble Tea program
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	// Run the program
 	if _, runErr := p.Run(); runErr != nil { // Changed variable name to runErr to avoid shadowing
-		log.Fatalf("Bubble Tea program exited with error: %v", runErr) // Log fatal error to file
+		logger.Error("Bubble Tea program exited with error", logging.KeyError, runErr) // Log fatal error to file
+		fmt.Fprintf(os.Stderr, "prompty: %v\n", runErr)
+		os.Exit(1)
+	}
+	logger.Info("Application exited cleanly")
+}
+
+// setupLogging creates the application's logger, writing to path, or one that
+// discards everything when disabled. The file is truncated on every start so
+// it doesn't grow forever. The logger also becomes slog's (and therefore the
+// standard log package's) default, so nothing logged elsewhere ends up on the
+// terminal. The returned function closes the log file.
+func setupLogging(path string, disabled bool, level string, redact bool) (*slog.Logger, func(), error) {
+	if disabled {
+		logger := logging.Discard()
+		slog.SetDefault(logger)
+		return logger, func() {}, nil
 	}
-	log.Println("Application exited cleanly.")
+
+	minLevel, err := logging.ParseLevel(level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, err
+	}
+	// 0644 means read/write for owner, read-only for others.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger := logging.New(f, minLevel, redact)
+	slog.SetDefault(logger)
+	return logger, func() { f.Close() }, nil
 }